@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bodowd/habits/data"
+)
+
+// resolveCalendar picks the home timezone and the hour days start at. The
+// --tz and --day-start flags take precedence over the HABITS_TZ and
+// HABITS_DAY_START environment variables. Without either the local timezone
+// and midnight are used.
+func resolveCalendar(tzFlag, dayStartFlag string, getenv func(string) string) (data.Calendar, error) {
+	tz := tzFlag
+	if tz == "" {
+		tz = getenv("HABITS_TZ")
+	}
+
+	dayStart := dayStartFlag
+	if dayStart == "" {
+		dayStart = getenv("HABITS_DAY_START")
+	}
+	hour := 0
+	if dayStart != "" {
+		var err error
+		hour, err = strconv.Atoi(dayStart)
+		if err != nil {
+			return data.Calendar{}, fmt.Errorf("invalid day start hour %q", dayStart)
+		}
+	}
+
+	return data.NewCalendar(tz, hour)
+}
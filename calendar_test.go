@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResolveCalendar(t *testing.T) {
+	tests := []struct {
+		name         string
+		tzFlag       string
+		dayStartFlag string
+		env          map[string]string
+		wantZone     string
+		wantHour     int
+	}{
+		{
+			name:     "defaults to the local timezone and midnight",
+			env:      map[string]string{},
+			wantZone: time.Local.String(),
+		},
+		{
+			name:     "environment",
+			env:      map[string]string{"HABITS_TZ": "Asia/Tokyo", "HABITS_DAY_START": "4"},
+			wantZone: "Asia/Tokyo",
+			wantHour: 4,
+		},
+		{
+			name:         "flags take precedence",
+			tzFlag:       "Europe/Berlin",
+			dayStartFlag: "5",
+			env:          map[string]string{"HABITS_TZ": "Asia/Tokyo", "HABITS_DAY_START": "4"},
+			wantZone:     "Europe/Berlin",
+			wantHour:     5,
+		},
+		{
+			name:     "flags and environment mix",
+			tzFlag:   "Europe/Berlin",
+			env:      map[string]string{"HABITS_DAY_START": "3"},
+			wantZone: "Europe/Berlin",
+			wantHour: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := resolveCalendar(tt.tzFlag, tt.dayStartFlag, getenvFrom(tt.env))
+			if err != nil {
+				t.Fatalf("did not expect an error, got %v", err)
+			}
+			if c.Location.String() != tt.wantZone {
+				t.Errorf("got timezone %s want %s", c.Location, tt.wantZone)
+			}
+			if c.DayStartHour != tt.wantHour {
+				t.Errorf("got day start hour %d want %d", c.DayStartHour, tt.wantHour)
+			}
+		})
+	}
+
+	invalid := []struct {
+		name         string
+		tzFlag       string
+		dayStartFlag string
+		env          map[string]string
+	}{
+		{name: "unknown timezone flag", tzFlag: "Mars/Olympus", env: map[string]string{}},
+		{name: "unknown timezone variable", env: map[string]string{"HABITS_TZ": "Mars/Olympus"}},
+		{name: "day start that is not a number", dayStartFlag: "four", env: map[string]string{}},
+		{name: "day start out of range", env: map[string]string{"HABITS_DAY_START": "24"}},
+		{name: "negative day start", dayStartFlag: "-1", env: map[string]string{}},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := resolveCalendar(tt.tzFlag, tt.dayStartFlag, getenvFrom(tt.env)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
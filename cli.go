@@ -0,0 +1,614 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/bodowd/habits/data"
+	"github.com/bodowd/habits/remind"
+	"gorm.io/gorm"
+)
+
+// exit codes of the non-interactive commands
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+const usage = `Usage: habits [--db path] [--tz zone] [--day-start hour] [command] [flags]
+
+Without a command the interactive habit list is started.
+
+The database is read from --db, then $HABITS_DB, then
+$XDG_DATA_HOME/habits/habits.db (~/.local/share/habits/habits.db).
+With DEMO=true the default file is demo.db instead.
+
+Days are those of the timezone given by --tz or $HABITS_TZ, the local one
+by default, and start at the hour given by --day-start or $HABITS_DAY_START,
+midnight by default. With --day-start 4 a habit done at 1am counts for the
+day before.
+
+Commands:
+  add <name>              start tracking a new habit
+      --tags a,b          tag it, e.g. with health,work
+      --remind HH:MM      remind of it at this time of day
+  done <name>             record a completion for today
+      --date YYYY-MM-DD   record it on an earlier day instead
+      --amount N          amount done, for habits with a target
+      --note TEXT         note how it went
+  archive <name>          stop tracking a habit
+      --date YYYY-MM-DD   archive it from an earlier day on
+  restore <name>          track an archived habit again
+      --date YYYY-MM-DD   restore it from an earlier day on
+      --keep-streak       don't let the days it was archived break the streak
+  list                    list the habits being tracked
+      --archived          list archived habits instead
+      --tag name          only list habits with this tag
+  streak <name>           print the current streak of a habit
+  reminder <name>         print when a habit is reminded of
+      --at HH:MM          remind of it at this time of day instead
+      --off               don't remind of it anymore
+  remind                  keep running and notify about habits that are due
+                          and not done yet once their reminder time comes
+      --notifier name     notify-send, bell or script, notify-send by default
+      --script path       program the script notifier runs with the habit
+                          and a message as its arguments
+      --interval 1m       how often to check for reminders
+      --once              notify about every reminder due so far today and exit
+  export                  export all habits and completions
+      --format json|csv   format of the export, json by default
+      --output path       write to a file instead of stdout
+  import <file>           import habits and completions, - reads stdin
+      --format name       json, csv, name-date or loop, detected by default
+      --dry-run           only report what would be imported
+  migrate                 back up the database and apply pending migrations
+      --status            only list the migrations and whether they are applied
+
+Every command but export and remind accepts --json to print JSON instead of plain text.
+`
+
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+type command struct {
+	flags *flag.FlagSet
+	run   func(db data.Database, args []string, out io.Writer) error
+}
+
+type habitOutput struct {
+	Name      string    `json:"name"`
+	Active    bool      `json:"active"`
+	Schedule  string    `json:"schedule"`
+	Target    float64   `json:"target,omitempty"`
+	Unit      string    `json:"unit,omitempty"`
+	CreatedAt data.Date `json:"created_at"`
+	RemindAt  string    `json:"remind_at,omitempty"`
+}
+
+type completionOutput struct {
+	Habit  string    `json:"habit"`
+	Date   data.Date `json:"date"`
+	Streak int       `json:"streak"`
+	Amount float64   `json:"amount,omitempty"`
+	Note   string    `json:"note,omitempty"`
+}
+
+type migrationOutput struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+type migrateOutput struct {
+	Backup     string            `json:"backup,omitempty"`
+	Migrations []migrationOutput `json:"migrations"`
+}
+
+type streakOutput struct {
+	Habit  string `json:"habit"`
+	Streak int    `json:"streak"`
+}
+
+func newHabitOutput(h data.Habit) habitOutput {
+	return habitOutput{
+		Name:      h.Name,
+		Active:    h.Active,
+		Schedule:  h.Schedule.String(),
+		Target:    h.Target,
+		Unit:      h.Unit,
+		CreatedAt: h.CreatedOn,
+		RemindAt:  h.RemindAt,
+	}
+}
+
+// runCommand runs the command named by args[0] and returns the exit code
+func runCommand(db data.Database, args []string, stdout, stderr io.Writer) int {
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+
+	cmd, ok := newCommands()[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	positional, err := parseInterspersed(cmd.flags, args[1:])
+	if err == nil {
+		err = cmd.run(db, positional, stdout)
+	}
+
+	var ue *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &ue):
+		fmt.Fprintf(stderr, "%s\n\n%s", err, usage)
+		return exitUsage
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		fmt.Fprintf(stderr, "habits %s: no such habit\n", args[0])
+		return exitError
+	default:
+		fmt.Fprintf(stderr, "habits %s: %s\n", args[0], err)
+		return exitError
+	}
+}
+
+// parseInterspersed parses fs allowing flags before, between and after the
+// positional arguments, which it returns
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, &usageError{msg: err.Error()}
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// habitName joins the positional arguments so names with spaces don't need quoting
+func habitName(args []string) (string, error) {
+	name := strings.TrimSpace(strings.Join(args, " "))
+	if name == "" {
+		return "", &usageError{msg: "missing habit name"}
+	}
+	return name, nil
+}
+
+func newCommands() map[string]command {
+	newFlags := func(name string) (*flag.FlagSet, *bool) {
+		fs := flag.NewFlagSet(name, flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		return fs, fs.Bool("json", false, "print JSON")
+	}
+
+	addFlags, addJSON := newFlags("add")
+	addTags := addFlags.String("tags", "", "comma separated tags")
+	addRemind := addFlags.String("remind", "", "time of day to be reminded, HH:MM")
+	doneFlags, doneJSON := newFlags("done")
+	doneDate := doneFlags.String("date", "", "day of the completion, YYYY-MM-DD")
+	doneAmount := doneFlags.Float64("amount", 0, "amount done")
+	doneNote := doneFlags.String("note", "", "note how it went")
+	archiveFlags, archiveJSON := newFlags("archive")
+	archiveDate := archiveFlags.String("date", "", "day the habit was archived, YYYY-MM-DD")
+	restoreFlags, restoreJSON := newFlags("restore")
+	restoreDate := restoreFlags.String("date", "", "day the habit was restored, YYYY-MM-DD")
+	restoreKeepStreak := restoreFlags.Bool("keep-streak", false, "don't let the days it was archived break the streak")
+	listFlags, listJSON := newFlags("list")
+	listArchived := listFlags.Bool("archived", false, "list archived habits")
+	listTag := listFlags.String("tag", "", "only list habits with this tag")
+	streakFlags, streakJSON := newFlags("streak")
+	reminderFlags, reminderJSON := newFlags("reminder")
+	reminderAt := reminderFlags.String("at", "", "time of day to be reminded, HH:MM")
+	reminderOff := reminderFlags.Bool("off", false, "remove the reminder")
+	remindFlags := flag.NewFlagSet("remind", flag.ContinueOnError)
+	remindFlags.SetOutput(io.Discard)
+	remindNotifier := remindFlags.String("notifier", "notify-send", "notify-send, bell or script")
+	remindScript := remindFlags.String("script", "", "program the script notifier runs")
+	remindInterval := remindFlags.Duration("interval", time.Minute, "how often to check for reminders")
+	remindOnce := remindFlags.Bool("once", false, "check once and exit")
+	exportFlags := flag.NewFlagSet("export", flag.ContinueOnError)
+	exportFlags.SetOutput(io.Discard)
+	exportFormat := exportFlags.String("format", data.FormatJSON, "json or csv")
+	exportOutput := exportFlags.String("output", "", "file to write to")
+	importFlags, importJSON := newFlags("import")
+	importFormat := importFlags.String("format", "", "json, csv, name-date or loop")
+	importDryRun := importFlags.Bool("dry-run", false, "only report what would be imported")
+	migrateFlags, migrateJSON := newFlags("migrate")
+	migrateStatus := migrateFlags.Bool("status", false, "only list the migrations")
+
+	return map[string]command{
+		"add": {addFlags, func(db data.Database, args []string, out io.Writer) error {
+			name, err := habitName(args)
+			if err != nil {
+				return err
+			}
+			remindAt, err := data.ParseReminder(*addRemind)
+			if err != nil {
+				return &usageError{msg: err.Error()}
+			}
+			h, err := db.CreateTaggedHabit(data.Habit{Name: name, RemindAt: remindAt}, data.ParseTags(*addTags))
+			if err != nil {
+				if strings.Contains(err.Error(), "UNIQUE") {
+					return fmt.Errorf("%s already exists", name)
+				}
+				return err
+			}
+			if *addJSON {
+				return writeJSON(out, newHabitOutput(h))
+			}
+			fmt.Fprintf(out, "Added %s as a new goal to track.\n", h.Name)
+			return nil
+		}},
+
+		"done": {doneFlags, func(db data.Database, args []string, out io.Writer) error {
+			name, err := habitName(args)
+			if err != nil {
+				return err
+			}
+			date, err := parseDateFlag(db, *doneDate)
+			if err != nil {
+				return err
+			}
+
+			var completion data.Completion
+			if *doneAmount != 0 {
+				completion, err = db.RecordAmountOn(name, date, *doneAmount)
+			} else {
+				completion, err = db.RecordCompletionOn(name, date)
+			}
+			if err != nil {
+				return err
+			}
+			if *doneNote != "" {
+				completion, err = db.SetCompletionNote(name, completion.RecordedAt.Time, *doneNote)
+				if err != nil {
+					return err
+				}
+			}
+
+			if *doneJSON {
+				return writeJSON(out, completionOutput{
+					Habit:  name,
+					Date:   completion.RecordedAt,
+					Streak: completion.Streak,
+					Amount: completion.Amount,
+					Note:   completion.Note,
+				})
+			}
+			fmt.Fprintf(out, "Recorded %s on %s. Streak: %d\n", name, completion.RecordedAt, completion.Streak)
+			return nil
+		}},
+
+		"archive": {archiveFlags, func(db data.Database, args []string, out io.Writer) error {
+			day, err := parseDateFlag(db, *archiveDate)
+			if err != nil {
+				return err
+			}
+			return setActive(db, args, out, false, day, false, *archiveJSON)
+		}},
+
+		"restore": {restoreFlags, func(db data.Database, args []string, out io.Writer) error {
+			day, err := parseDateFlag(db, *restoreDate)
+			if err != nil {
+				return err
+			}
+			return setActive(db, args, out, true, day, *restoreKeepStreak, *restoreJSON)
+		}},
+
+		"list": {listFlags, func(db data.Database, args []string, out io.Writer) error {
+			if len(args) != 0 {
+				return &usageError{msg: "list takes no arguments"}
+			}
+			habits := db.GetActiveHabitsTagged(*listTag)
+			if *listArchived {
+				habits = db.GetInactiveHabitsTagged(*listTag)
+			}
+
+			if *listJSON {
+				output := make([]habitOutput, len(habits))
+				for i, h := range habits {
+					output[i] = newHabitOutput(h)
+				}
+				return writeJSON(out, output)
+			}
+			for _, h := range habits {
+				fmt.Fprintln(out, h.Name)
+			}
+			return nil
+		}},
+
+		"streak": {streakFlags, func(db data.Database, args []string, out io.Writer) error {
+			name, err := habitName(args)
+			if err != nil {
+				return err
+			}
+			streak, err := db.CurrentStreak(name)
+			if err != nil {
+				return err
+			}
+			if *streakJSON {
+				return writeJSON(out, streakOutput{Habit: name, Streak: streak})
+			}
+			fmt.Fprintln(out, streak)
+			return nil
+		}},
+
+		"reminder": {reminderFlags, func(db data.Database, args []string, out io.Writer) error {
+			name, err := habitName(args)
+			if err != nil {
+				return err
+			}
+			if *reminderAt != "" && *reminderOff {
+				return &usageError{msg: "reminder takes either --at or --off"}
+			}
+
+			var h data.Habit
+			switch {
+			case *reminderOff:
+				h, err = db.SetReminder(name, "")
+			case *reminderAt != "":
+				if _, err := data.ParseReminder(*reminderAt); err != nil {
+					return &usageError{msg: err.Error()}
+				}
+				h, err = db.SetReminder(name, *reminderAt)
+			default:
+				h, err = db.GetHabit(name)
+			}
+			if err != nil {
+				return err
+			}
+
+			if *reminderJSON {
+				return writeJSON(out, newHabitOutput(h))
+			}
+			if h.RemindAt == "" {
+				fmt.Fprintf(out, "%s has no reminder\n", h.Name)
+				return nil
+			}
+			fmt.Fprintf(out, "%s is reminded of at %s\n", h.Name, h.RemindAt)
+			return nil
+		}},
+
+		"remind": {remindFlags, func(db data.Database, args []string, out io.Writer) error {
+			if len(args) != 0 {
+				return &usageError{msg: "remind takes no arguments"}
+			}
+			if *remindInterval <= 0 {
+				return &usageError{msg: "the interval must be positive"}
+			}
+			notifier, err := newNotifier(*remindNotifier, *remindScript, out)
+			if err != nil {
+				return err
+			}
+
+			r := remind.NewReminder(db, notifier)
+			if *remindOnce {
+				_, err := r.Check()
+				return err
+			}
+			ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return r.Run(ctx, *remindInterval, os.Stderr)
+		}},
+
+		"export": {exportFlags, func(db data.Database, args []string, out io.Writer) error {
+			if len(args) != 0 {
+				return &usageError{msg: "export takes no arguments"}
+			}
+			if *exportFormat != data.FormatJSON && *exportFormat != data.FormatCSV {
+				return &usageError{msg: (&data.UnknownFormatError{Format: *exportFormat}).Error()}
+			}
+			if *exportOutput == "" {
+				return db.WriteExport(out, *exportFormat)
+			}
+
+			f, err := os.Create(*exportOutput)
+			if err != nil {
+				return err
+			}
+			if err := db.WriteExport(f, *exportFormat); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		}},
+
+		"import": {importFlags, func(db data.Database, args []string, out io.Writer) error {
+			if len(args) != 1 {
+				return &usageError{msg: "import takes exactly one file"}
+			}
+
+			in := os.Stdin
+			if args[0] != "-" {
+				f, err := os.Open(args[0])
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				in = f
+			}
+
+			report, err := db.Import(in, *importFormat, *importDryRun)
+			if err != nil {
+				return err
+			}
+			if *importJSON {
+				return writeJSON(out, report)
+			}
+			printImportReport(out, report)
+			return nil
+		}},
+
+		"migrate": {migrateFlags, func(db data.Database, args []string, out io.Writer) error {
+			if len(args) > 0 {
+				return &usageError{msg: "migrate takes no arguments"}
+			}
+
+			var backup string
+			if !*migrateStatus {
+				var err error
+				backup, err = data.Migrate(db.DB)
+				if err != nil {
+					return err
+				}
+			}
+			states, err := data.MigrationStatus(db.DB)
+			if err != nil {
+				return err
+			}
+
+			if *migrateJSON {
+				result := migrateOutput{Backup: backup, Migrations: []migrationOutput{}}
+				for _, s := range states {
+					m := migrationOutput{Version: s.Version, Name: s.Name, Applied: s.Applied}
+					if s.Applied {
+						m.AppliedAt = &s.AppliedAt
+					}
+					result.Migrations = append(result.Migrations, m)
+				}
+				return writeJSON(out, result)
+			}
+			if backup != "" {
+				fmt.Fprintf(out, "Backed up the database to %s\n", backup)
+			}
+			width := 0
+			for _, s := range states {
+				if len(s.Name) > width {
+					width = len(s.Name)
+				}
+			}
+			for _, s := range states {
+				status := "pending"
+				if s.Applied {
+					status = "applied " + s.AppliedAt.Local().Format("2006-01-02 15:04")
+				}
+				fmt.Fprintf(out, "%3d  %-*s  %s\n", s.Version, width, s.Name, status)
+			}
+			return nil
+		}},
+	}
+}
+
+// newNotifier returns the notifier named by name. The bell writes to out.
+func newNotifier(name, script string, out io.Writer) (remind.Notifier, error) {
+	switch name {
+	case "notify-send":
+		return remind.NotifySend{}, nil
+	case "bell":
+		return remind.Bell{Out: out}, nil
+	case "script":
+		if script == "" {
+			return nil, &usageError{msg: "the script notifier needs --script"}
+		}
+		return remind.Script{Path: script}, nil
+	}
+	return nil, &usageError{msg: fmt.Sprintf("unknown notifier %q, expected notify-send, bell or script", name)}
+}
+
+// managesMigrations reports whether the command takes care of migrating the
+// database itself, which is otherwise done as soon as it is opened
+func managesMigrations(args []string) bool {
+	return len(args) > 0 && args[0] == "migrate"
+}
+
+func printImportReport(out io.Writer, report data.ImportReport) {
+	if report.DryRun {
+		fmt.Fprintln(out, "Dry run, nothing was written.")
+	}
+	for _, name := range report.NewHabits {
+		fmt.Fprintf(out, "new habit: %s\n", name)
+	}
+	for _, d := range report.Duplicates {
+		fmt.Fprintf(out, "duplicate: %s on %s\n", d.Habit, d.Date)
+	}
+	for _, c := range report.Conflicts {
+		if c.Habit == "" {
+			fmt.Fprintf(out, "conflict: row %d: %s\n", c.Row, c.Reason)
+			continue
+		}
+		fmt.Fprintf(out, "conflict: %s on %s: %s\n", c.Habit, c.Date, c.Reason)
+	}
+	verb := "imported"
+	if report.DryRun {
+		verb = "would be imported"
+	}
+	fmt.Fprintf(out, "%d completions %s, %d duplicates and %d conflicts skipped\n",
+		report.Imported, verb, len(report.Duplicates), len(report.Conflicts))
+}
+
+// parseDateFlag reads a YYYY-MM-DD flag value, defaulting to today
+func parseDateFlag(db data.Database, value string) (time.Time, error) {
+	if value == "" {
+		return db.Today().Time, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return date, &usageError{msg: fmt.Sprintf("invalid date %q, expected YYYY-MM-DD", value)}
+	}
+	return date, nil
+}
+
+func setActive(db data.Database, args []string, out io.Writer, active bool, day time.Time, keepStreak, asJSON bool) error {
+	name, err := habitName(args)
+	if err != nil {
+		return err
+	}
+	h, err := db.GetHabit(name)
+	if err != nil {
+		return err
+	}
+	if h.Active == active {
+		if active {
+			return fmt.Errorf("%s is not archived", name)
+		}
+		return fmt.Errorf("%s is already archived", name)
+	}
+
+	if active {
+		err = db.RestoreHabitOn(name, day, keepStreak)
+	} else {
+		err = db.ArchiveHabitOn(name, day)
+	}
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		h.Active = active
+		return writeJSON(out, newHabitOutput(h))
+	}
+	if active {
+		fmt.Fprintf(out, "Restored %s\n", name)
+	} else {
+		fmt.Fprintf(out, "Archived %s\n", name)
+	}
+	return nil
+}
+
+func writeJSON(out io.Writer, v interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bodowd/habits/data"
+)
+
+var testNow = time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)
+
+func TestRunCommand(t *testing.T) {
+	db := openTestDB(t)
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "adds a habit",
+			args:       []string{"add", "read", "books"},
+			wantCode:   exitOK,
+			wantStdout: "Added read books as a new goal to track.\n",
+		},
+		{
+			name:       "records a completion",
+			args:       []string{"done", "read books"},
+			wantCode:   exitOK,
+			wantStdout: "Recorded read books on 2024-03-15. Streak: 1\n",
+		},
+		{
+			name:       "prints the streak",
+			args:       []string{"streak", "read books"},
+			wantCode:   exitOK,
+			wantStdout: "1\n",
+		},
+		{
+			name:       "lists habits",
+			args:       []string{"list"},
+			wantCode:   exitOK,
+			wantStdout: "read books\n",
+		},
+		{
+			name:       "prints the usage on help",
+			args:       []string{"help"},
+			wantCode:   exitOK,
+			wantStdout: usage,
+		},
+		{
+			name:       "rejects a duplicate habit",
+			args:       []string{"add", "read books"},
+			wantCode:   exitError,
+			wantStderr: "habits add: read books already exists\n",
+		},
+		{
+			name:       "rejects an unknown habit",
+			args:       []string{"done", "fly"},
+			wantCode:   exitError,
+			wantStderr: "habits done: no such habit\n",
+		},
+		{
+			name:       "rejects a second completion on the same day",
+			args:       []string{"done", "read books"},
+			wantCode:   exitError,
+			wantStderr: "habits done: Already recorded completion for today\n",
+		},
+		{
+			name:       "rejects an invalid reminder",
+			args:       []string{"add", "--remind", "noon", "cook"},
+			wantCode:   exitUsage,
+			wantStderr: "Invalid reminder time \"noon\", expected something like 08:30\n\n" + usage,
+		},
+		{
+			name:       "adds a habit with tags and a reminder",
+			args:       []string{"add", "--tags", "health", "--remind", "7:00", "--json", "swim"},
+			wantCode:   exitOK,
+			wantStdout: "{\n  \"name\": \"swim\",\n  \"active\": true,\n  \"schedule\": \"daily\",\n  \"created_at\": \"2024-03-15\",\n  \"remind_at\": \"07:00\"\n}\n",
+		},
+		{
+			name:       "lists habits by tag",
+			args:       []string{"list", "--tag", "health"},
+			wantCode:   exitOK,
+			wantStdout: "swim\n",
+		},
+		{
+			name:       "rejects an unknown command",
+			args:       []string{"fly"},
+			wantCode:   exitUsage,
+			wantStderr: "unknown command \"fly\"\n\n" + usage,
+		},
+		{
+			name:       "rejects an unknown flag",
+			args:       []string{"add", "--colour", "red", "cook"},
+			wantCode:   exitUsage,
+			wantStderr: "flag provided but not defined: -colour\n\n" + usage,
+		},
+		{
+			name:       "rejects a missing habit name",
+			args:       []string{"streak"},
+			wantCode:   exitUsage,
+			wantStderr: "missing habit name\n\n" + usage,
+		},
+		{
+			name:       "rejects an invalid date",
+			args:       []string{"done", "--date", "yesterday", "read books"},
+			wantCode:   exitUsage,
+			wantStderr: "invalid date \"yesterday\", expected YYYY-MM-DD\n\n" + usage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, stdout, stderr := run(db, tt.args...)
+			if code != tt.wantCode {
+				t.Errorf("got exit code %d want %d, stderr %q", code, tt.wantCode, stderr)
+			}
+			if stdout != tt.wantStdout {
+				t.Errorf("got stdout %q want %q", stdout, tt.wantStdout)
+			}
+			if stderr != tt.wantStderr {
+				t.Errorf("got stderr %q want %q", stderr, tt.wantStderr)
+			}
+		})
+	}
+}
+
+func TestRunCommandJSON(t *testing.T) {
+	db := openTestDB(t)
+
+	if code, _, stderr := run(db, "add", "cook"); code != exitOK {
+		t.Fatalf("got exit code %d: %s", code, stderr)
+	}
+
+	code, stdout, stderr := run(db, "done", "cook", "--json", "--note", "pasta")
+	if code != exitOK {
+		t.Fatalf("got exit code %d: %s", code, stderr)
+	}
+	var got completionOutput
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("unable to read %q: %v", stdout, err)
+	}
+	want := completionOutput{Habit: "cook", Date: data.DateOf(testNow), Streak: 1, Note: "pasta"}
+	if !got.Date.Equal(want.Date) || got.Habit != want.Habit || got.Streak != want.Streak || got.Note != want.Note {
+		t.Errorf("got %+v want %+v", got, want)
+	}
+}
+
+func TestParseInterspersed(t *testing.T) {
+	fs := newCommands()["done"].flags
+	got, err := parseInterspersed(fs, []string{"read", "--note", "a chapter", "books"})
+	if err != nil {
+		t.Fatalf("did not expect an error, got %v", err)
+	}
+	if strings.Join(got, " ") != "read books" {
+		t.Errorf("got %v want [read books]", got)
+	}
+	if note := fs.Lookup("note").Value.String(); note != "a chapter" {
+		t.Errorf("got note %q want %q", note, "a chapter")
+	}
+}
+
+// run runs the command given by args and returns its exit code and output
+func run(db data.Database, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := runCommand(db, args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// openTestDB returns a migrated database in a temporary file whose clock
+// stands still at testNow
+func openTestDB(t *testing.T) data.Database {
+	t.Helper()
+	db := openSQLite(filepath.Join(t.TempDir(), "habits.db"))
+	migrate(db)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return data.Database{
+		DB:       db,
+		Calendar: data.Calendar{Location: time.UTC},
+		Clock:    data.ClockFunc(func() time.Time { return testNow }),
+	}
+}
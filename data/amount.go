@@ -0,0 +1,125 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type NotQuantitativeError struct {
+	Habit string
+}
+
+func (e *NotQuantitativeError) Error() string {
+	return fmt.Sprintf("%s does not track an amount", e.Habit)
+}
+
+type InvalidTargetError struct {
+	Target string
+}
+
+func (e *InvalidTargetError) Error() string {
+	return fmt.Sprintf("Invalid target %q, expected something like \"8 glasses\"", e.Target)
+}
+
+// ParseTarget splits a target such as "500 words" into its amount and unit.
+// An empty string means the habit has no target.
+func ParseTarget(s string) (float64, string, error) {
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return 0, "", nil
+	}
+
+	target, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil || target <= 0 {
+		return 0, "", &InvalidTargetError{Target: s}
+	}
+	return target, strings.Join(fields[1:], " "), nil
+}
+
+// FormatAmount renders an amount without trailing zeros, followed by unit
+func FormatAmount(amount float64, unit string) string {
+	s := strconv.FormatFloat(amount, 'f', -1, 64)
+	if unit == "" {
+		return s
+	}
+	return s + " " + unit
+}
+
+func (d *Database) CreateQuantitativeHabit(name string, schedule Schedule, target float64, unit string) (Habit, error) {
+	hab := Habit{
+		Name:      name,
+		CreatedOn: d.Today(),
+		Active:    true,
+		Schedule:  schedule,
+		Target:    target,
+		Unit:      unit,
+	}
+	return d.createHabit(hab, nil)
+}
+
+// RecordAmount adds amount to today's total of a quantitative habit
+func (d *Database) RecordAmount(habit string, amount float64) (Completion, error) {
+	return d.RecordAmountOn(habit, d.Today().Time, amount)
+}
+
+// RecordAmountOn adds amount to the total of a quantitative habit on date.
+// All amounts of one day are summed into a single completion, which only
+// counts towards the streak once it reaches the habit's target. A negative
+// amount takes back an earlier entry; the completion is removed when its
+// total drops to zero.
+func (d *Database) RecordAmountOn(habit string, date time.Time, amount float64) (Completion, error) {
+	day := DateOf(date)
+	if day.After(d.Today()) {
+		return Completion{}, &FutureDateError{Date: day.String()}
+	}
+
+	h, err := d.getActiveHabitByName(habit)
+	if err != nil {
+		return Completion{}, err
+	}
+	if !h.IsQuantitative() {
+		return Completion{}, &NotQuantitativeError{Habit: habit}
+	}
+
+	var completion Completion
+	removed := false
+	err = d.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("habit_id = ? AND recorded_at = ?", h.ID, day).First(&completion).Error
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			if amount <= 0 {
+				return err
+			}
+			completion = Completion{RecordedAt: day, HabitID: h.ID, Amount: amount}
+			err = tx.Create(&completion).Error
+		case err != nil:
+			return err
+		case completion.Amount+amount <= 0:
+			removed = true
+			err = tx.Delete(&completion).Error
+		default:
+			err = tx.Model(&completion).Update("amount", completion.Amount+amount).Error
+		}
+		if err != nil {
+			return err
+		}
+		return recomputeStreaksFrom(tx, h.ID, day)
+	})
+	if err != nil {
+		return Completion{}, err
+	}
+
+	if removed {
+		completion.Amount = 0
+		completion.Streak = 0
+		return completion, nil
+	}
+
+	err = d.DB.First(&completion, completion.ID).Error
+	return completion, err
+}
@@ -0,0 +1,91 @@
+package data
+
+import (
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	target, unit, err := ParseTarget(" 500 words ")
+	didNotExpectError(t, err)
+	if target != 500 || unit != "words" {
+		t.Errorf("got %v %q want %v %q", target, unit, 500, "words")
+	}
+
+	target, _, err = ParseTarget("")
+	didNotExpectError(t, err)
+	if target != 0 {
+		t.Errorf("got %v want %v", target, 0)
+	}
+
+	_, _, err = ParseTarget("lots of words")
+	if _, ok := err.(*InvalidTargetError); !ok {
+		t.Errorf("expected invalid target error, got %v", err)
+	}
+}
+
+func TestRecordAmount(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	_, err := g.CreateQuantitativeHabit("drink water", DailySchedule(), 8, "glasses")
+	didNotExpectError(t, err)
+
+	t.Run("does not count towards the streak below the target", func(t *testing.T) {
+		got, err := g.RecordAmountOn("drink water", testNow.AddDate(0, 0, -1), 5)
+		didNotExpectError(t, err)
+
+		if got.Streak != 0 {
+			t.Errorf("got %d want %d", got.Streak, 0)
+		}
+	})
+
+	t.Run("sums amounts of the same day", func(t *testing.T) {
+		got, err := g.RecordAmountOn("drink water", testNow.AddDate(0, 0, -1), 3)
+		didNotExpectError(t, err)
+
+		if got.Amount != 8 {
+			t.Errorf("got %v want %v", got.Amount, 8)
+		}
+		if got.Streak != 1 {
+			t.Errorf("got %d want %d", got.Streak, 1)
+		}
+	})
+
+	t.Run("continues the streak once the target is met", func(t *testing.T) {
+		got, err := g.RecordAmount("drink water", 10)
+		didNotExpectError(t, err)
+
+		if got.Streak != 2 {
+			t.Errorf("got %d want %d", got.Streak, 2)
+		}
+	})
+
+	t.Run("taking back an amount breaks the streak again", func(t *testing.T) {
+		_, err := g.RecordAmountOn("drink water", testNow.AddDate(0, 0, -1), -1)
+		didNotExpectError(t, err)
+
+		var todays Completion
+		db.Where("recorded_at = ? AND amount = ?", today(), 10).First(&todays)
+		if todays.Streak != 1 {
+			t.Errorf("got %d want %d", todays.Streak, 1)
+		}
+	})
+
+	t.Run("removes the completion when the amount drops to zero", func(t *testing.T) {
+		got, err := g.RecordAmount("drink water", -10)
+		didNotExpectError(t, err)
+
+		if got.Amount != 0 {
+			t.Errorf("got %v want %v", got.Amount, 0)
+		}
+		_, err = g.getCompletionAtTime("drink water", today())
+		assertRecordNotFound(t, err)
+	})
+
+	t.Run("does not record amounts for yes/no habits", func(t *testing.T) {
+		_, err := g.RecordAmount("cook", 1)
+		if _, ok := err.(*NotQuantitativeError); !ok {
+			t.Errorf("expected not quantitative error, got %v", err)
+		}
+	})
+}
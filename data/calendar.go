@@ -0,0 +1,86 @@
+package data
+
+import (
+	"fmt"
+	"time"
+)
+
+// Clock tells the current time. Databases use the system clock unless
+// given another one, e.g. to pin the time in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc adapts a function to a Clock
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// SystemClock is the clock of the machine
+var SystemClock Clock = ClockFunc(time.Now)
+
+// Calendar decides which day a moment belongs to. Days are those of the home
+// timezone and start at DayStartHour, so a habit done at 1am can still count
+// for the day before. The zero Calendar uses the local timezone and midnight.
+type Calendar struct {
+	Location     *time.Location
+	DayStartHour int
+}
+
+type InvalidDayStartError struct {
+	Hour int
+}
+
+func (e *InvalidDayStartError) Error() string {
+	return fmt.Sprintf("Day start hour must be between 0 and 23, got %d", e.Hour)
+}
+
+// NewCalendar returns a calendar for the timezone named like "Europe/Berlin",
+// or the local timezone if name is empty, with days starting at dayStartHour
+func NewCalendar(name string, dayStartHour int) (Calendar, error) {
+	if dayStartHour < 0 || dayStartHour > 23 {
+		return Calendar{}, &InvalidDayStartError{Hour: dayStartHour}
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return Calendar{}, err
+	}
+	if name == "" {
+		loc = time.Local
+	}
+	return Calendar{Location: loc, DayStartHour: dayStartHour}, nil
+}
+
+// DateOf returns the day t falls on in the home timezone. Times before the
+// day start hour belong to the previous day.
+func (c Calendar) DateOf(t time.Time) Date {
+	t = t.In(c.location())
+	day := DateOf(t)
+	if t.Hour() < c.DayStartHour {
+		return day.AddDays(-1)
+	}
+	return day
+}
+
+func (c Calendar) location() *time.Location {
+	if c.Location == nil {
+		return time.Local
+	}
+	return c.Location
+}
+
+// Now returns the time according to the database's clock
+func (d *Database) Now() time.Time {
+	if d.Clock == nil {
+		return SystemClock.Now()
+	}
+	return d.Clock.Now()
+}
+
+// Today returns the day it is now according to the database's clock and
+// calendar
+func (d *Database) Today() Date {
+	return d.Calendar.DateOf(d.Now())
+}
@@ -0,0 +1,188 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock the tests set by hand
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestCalendarDateOf(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	didNotExpectError(t, err)
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	didNotExpectError(t, err)
+
+	cases := []struct {
+		name     string
+		calendar Calendar
+		at       time.Time
+		want     Date
+	}{
+		{"midnight rollover", Calendar{Location: berlin},
+			time.Date(2023, time.March, 1, 0, 30, 0, 0, berlin), NewDate(2023, time.March, 1)},
+		{"before the day start hour", Calendar{Location: berlin, DayStartHour: 4},
+			time.Date(2023, time.March, 1, 1, 0, 0, 0, berlin), NewDate(2023, time.February, 28)},
+		{"at the day start hour", Calendar{Location: berlin, DayStartHour: 4},
+			time.Date(2023, time.March, 1, 4, 0, 0, 0, berlin), NewDate(2023, time.March, 1)},
+		{"day start on a leap day", Calendar{Location: berlin, DayStartHour: 4},
+			time.Date(2024, time.March, 1, 3, 59, 0, 0, berlin), NewDate(2024, time.February, 29)},
+		{"home timezone while travelling", Calendar{Location: berlin},
+			time.Date(2023, time.January, 1, 7, 0, 0, 0, tokyo), NewDate(2022, time.December, 31)},
+		{"new year with a late day start", Calendar{Location: tokyo, DayStartHour: 5},
+			time.Date(2023, time.January, 1, 2, 0, 0, 0, tokyo), NewDate(2022, time.December, 31)},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.calendar.DateOf(tc.at)
+			if !got.Equal(tc.want) {
+				t.Errorf("got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewCalendar(t *testing.T) {
+	c, err := NewCalendar("Europe/Berlin", 4)
+	didNotExpectError(t, err)
+	if c.Location.String() != "Europe/Berlin" || c.DayStartHour != 4 {
+		t.Errorf("got %v %d want Europe/Berlin 4", c.Location, c.DayStartHour)
+	}
+
+	_, err = NewCalendar("", 24)
+	if _, ok := err.(*InvalidDayStartError); !ok {
+		t.Errorf("expected invalid day start error, got %v", err)
+	}
+
+	_, err = NewCalendar("Nowhere/Special", 0)
+	if err == nil {
+		t.Error("expected an error for an unknown timezone")
+	}
+}
+
+func TestRecordCompletionAfterMidnight(t *testing.T) {
+	db := setup(t)
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	didNotExpectError(t, err)
+	clock := &fakeClock{}
+	g := Database{DB: db, Calendar: Calendar{Location: berlin, DayStartHour: 4}, Clock: clock}
+
+	clock.now = time.Date(2023, time.February, 27, 22, 0, 0, 0, berlin)
+	_, err = g.CreateHabit("stretch")
+	didNotExpectError(t, err)
+
+	_, err = g.RecordCompletion("stretch")
+	didNotExpectError(t, err)
+
+	// 1am is still the evening of the 28th
+	clock.now = time.Date(2023, time.March, 1, 1, 0, 0, 0, berlin)
+	got, err := g.RecordCompletion("stretch")
+	didNotExpectError(t, err)
+	if !got.RecordedAt.Equal(NewDate(2023, time.February, 28)) {
+		t.Errorf("got %v want %v", got.RecordedAt, "2023-02-28")
+	}
+	if got.Streak != 2 {
+		t.Errorf("got %d want %d", got.Streak, 2)
+	}
+
+	streak, err := g.CurrentStreak("stretch")
+	didNotExpectError(t, err)
+	if streak != 2 {
+		t.Errorf("got %d want %d", streak, 2)
+	}
+}
+
+func TestDayRollovers(t *testing.T) {
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+
+	cases := []struct {
+		name  string
+		times []time.Time
+		// streak after each completion, 0 where it was already recorded
+		want []int
+	}{
+		{"a minute before and after midnight",
+			[]time.Time{at(2023, time.June, 1, 23, 59), at(2023, time.June, 2, 0, 1)}, []int{1, 2}},
+		{"twice on the same day",
+			[]time.Time{at(2023, time.June, 1, 0, 0), at(2023, time.June, 1, 23, 59)}, []int{1, 0}},
+		{"month end",
+			[]time.Time{at(2023, time.April, 30, 20, 0), at(2023, time.May, 1, 8, 0)}, []int{1, 2}},
+		{"year end",
+			[]time.Time{at(2022, time.December, 31, 20, 0), at(2023, time.January, 1, 8, 0)}, []int{1, 2}},
+		{"february in a leap year",
+			[]time.Time{at(2024, time.February, 28, 9, 0), at(2024, time.February, 29, 9, 0),
+				at(2024, time.March, 1, 9, 0)}, []int{1, 2, 3}},
+		{"skipping the leap day",
+			[]time.Time{at(2024, time.February, 28, 9, 0), at(2024, time.March, 1, 9, 0)}, []int{1, 1}},
+		{"february in a common year",
+			[]time.Time{at(2023, time.February, 28, 9, 0), at(2023, time.March, 1, 9, 0)}, []int{1, 2}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := setup(t)
+			clock := &fakeClock{now: tc.times[0]}
+			g := Database{DB: db, Calendar: Calendar{Location: time.UTC}, Clock: clock}
+
+			_, err := g.CreateHabit("stretch")
+			didNotExpectError(t, err)
+
+			for i, now := range tc.times {
+				clock.now = now
+				got, err := g.RecordCompletion("stretch")
+				if tc.want[i] == 0 {
+					if _, ok := err.(*AlreadyRecordedTodayError); !ok {
+						t.Errorf("expected already recorded error at %v, got %v", now, err)
+					}
+					continue
+				}
+				didNotExpectError(t, err)
+				if got.Streak != tc.want[i] {
+					t.Errorf("got streak %d at %v want %d", got.Streak, now, tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCurrentStreakAfterRollover(t *testing.T) {
+	db := setup(t)
+	clock := &fakeClock{now: time.Date(2024, time.February, 28, 21, 0, 0, 0, time.UTC)}
+	g := Database{DB: db, Calendar: Calendar{Location: time.UTC}, Clock: clock}
+
+	_, err := g.CreateHabit("stretch")
+	didNotExpectError(t, err)
+	_, err = g.RecordCompletion("stretch")
+	didNotExpectError(t, err)
+
+	for _, tc := range []struct {
+		now  time.Time
+		want int
+	}{
+		// the streak can still be continued on the leap day
+		{time.Date(2024, time.February, 29, 23, 59, 0, 0, time.UTC), 1},
+		{time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC), 0},
+	} {
+		clock.now = tc.now
+		got, err := g.CurrentStreak("stretch")
+		didNotExpectError(t, err)
+		if got != tc.want {
+			t.Errorf("got %d at %v want %d", got, tc.now, tc.want)
+		}
+	}
+
+	_, err = g.RecordCompletionOn("stretch", time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC))
+	if _, ok := err.(*FutureDateError); !ok {
+		t.Errorf("expected future date error, got %v", err)
+	}
+}
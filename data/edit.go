@@ -0,0 +1,128 @@
+package data
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type HabitExistsError struct {
+	Name string
+}
+
+func (e *HabitExistsError) Error() string {
+	return fmt.Sprintf("A habit named %s already exists", e.Name)
+}
+
+type EmptyNameError struct{}
+
+func (e *EmptyNameError) Error() string {
+	return "A habit needs a name"
+}
+
+type InvalidColorError struct {
+	Color string
+}
+
+func (e *InvalidColorError) Error() string {
+	return fmt.Sprintf("Invalid colour %q, expected something like #ff8800 or a number from 0 to 255", e.Color)
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-f]{3}|[0-9a-f]{6})$`)
+
+// ParseColor accepts hex colours like "#ff8800" and ANSI colour numbers from
+// 0 to 255. An empty string means the default colour.
+func ParseColor(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" || hexColor.MatchString(s) {
+		return s, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 255 {
+		return s, nil
+	}
+	return "", &InvalidColorError{Color: s}
+}
+
+// HabitEdit holds the settings of a habit that can be changed after it was
+// created
+type HabitEdit struct {
+	Name        string
+	Description string
+	Schedule    Schedule
+	Color       string
+	// Tags replace the habit's tags, see ParseTags
+	Tags []string
+	// RemindAt is the time of day to be reminded, see ParseReminder
+	RemindAt string
+}
+
+// RenameHabit changes the name of habit. Its completions stay linked to it.
+func (d *Database) RenameHabit(habit, name string) error {
+	h, err := d.GetHabit(habit)
+	if err != nil {
+		return err
+	}
+	_, err = d.EditHabit(habit, HabitEdit{
+		Name:        name,
+		Description: h.Description,
+		Schedule:    h.Schedule,
+		Color:       h.Color,
+		Tags:        h.TagNames(),
+		RemindAt:    h.RemindAt,
+	})
+	return err
+}
+
+// EditHabit changes the settings of habit. The streaks of all its
+// completions are recomputed if the schedule changed.
+func (d *Database) EditHabit(habit string, edit HabitEdit) (Habit, error) {
+	edit.Name = strings.TrimSpace(edit.Name)
+	if edit.Name == "" {
+		return Habit{}, &EmptyNameError{}
+	}
+	color, err := ParseColor(edit.Color)
+	if err != nil {
+		return Habit{}, err
+	}
+	remindAt, err := ParseReminder(edit.RemindAt)
+	if err != nil {
+		return Habit{}, err
+	}
+
+	var h Habit
+	err = d.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("name = ?", habit).First(&h).Error; err != nil {
+			return err
+		}
+		rescheduled := h.Schedule != edit.Schedule
+
+		h.Name = edit.Name
+		h.Description = strings.TrimSpace(edit.Description)
+		h.Schedule = edit.Schedule
+		h.Color = color
+		h.RemindAt = remindAt
+		err := tx.Model(&h).
+			Select("name", "description", "color", "schedule_kind", "schedule_weekdays", "schedule_count", "remind_at").
+			Updates(&h).Error
+		if err != nil {
+			if strings.Contains(err.Error(), "UNIQUE") {
+				return &HabitExistsError{Name: edit.Name}
+			}
+			return err
+		}
+
+		if err := setTags(tx, &h, edit.Tags); err != nil {
+			return err
+		}
+
+		if rescheduled {
+			// the zero date comes before every completion
+			return recomputeStreaksFrom(tx, h.ID, Date{})
+		}
+		return nil
+	})
+	return h, err
+}
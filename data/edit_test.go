@@ -0,0 +1,102 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRenameHabit(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	t.Run("keeps the completions of the habit", func(t *testing.T) {
+		didNotExpectError(t, g.RenameHabit("read", "read books"))
+
+		_, err := g.GetHabit("read")
+		assertRecordNotFound(t, err)
+
+		h, err := g.GetHabit("read books")
+		didNotExpectError(t, err)
+		var count int64
+		db.Model(&Completion{}).Where("habit_id = ?", h.ID).Count(&count)
+		if count != 3 {
+			t.Errorf("got %d completions want %d", count, 3)
+		}
+	})
+
+	t.Run("does not take the name of another habit", func(t *testing.T) {
+		err := g.RenameHabit("cook", "garden")
+		if _, ok := err.(*HabitExistsError); !ok {
+			t.Errorf("expected habit exists error, got %v", err)
+		}
+	})
+
+	t.Run("does not allow an empty name", func(t *testing.T) {
+		err := g.RenameHabit("cook", "  ")
+		if _, ok := err.(*EmptyNameError); !ok {
+			t.Errorf("expected empty name error, got %v", err)
+		}
+	})
+}
+
+func TestEditHabit(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	weekly, _ := ParseSchedule(ScheduleWeekly, "1")
+	h, err := g.EditHabit("garden", HabitEdit{
+		Name:        "garden",
+		Description: " water the tomatoes ",
+		Schedule:    weekly,
+		Color:       "#2E8B57",
+		Tags:        []string{"outdoors"},
+	})
+	didNotExpectError(t, err)
+	if !reflect.DeepEqual(h.TagNames(), []string{"outdoors"}) {
+		t.Errorf("got tags %v want [outdoors]", h.TagNames())
+	}
+
+	got, err := g.GetHabit("garden")
+	didNotExpectError(t, err)
+	if got.Description != "water the tomatoes" || got.Color != "#2e8b57" || got.Schedule != weekly {
+		t.Errorf("got %+v want the edited settings", got)
+	}
+
+	// the seeded streak of 510 didn't fit any schedule
+	var c Completion
+	db.Where("habit_id = ?", h.ID).First(&c)
+	if c.Streak != 1 {
+		t.Errorf("got streak %d want %d after changing the schedule", c.Streak, 1)
+	}
+
+	_, err = g.EditHabit("garden", HabitEdit{Name: "garden", Schedule: weekly, Color: "teal"})
+	if _, ok := err.(*InvalidColorError); !ok {
+		t.Errorf("expected invalid colour error, got %v", err)
+	}
+}
+
+func TestParseColor(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		want  string
+		valid bool
+	}{
+		{"", "", true},
+		{"#FFF", "#fff", true},
+		{"#ff8800", "#ff8800", true},
+		{" 42 ", "42", true},
+		{"256", "", false},
+		{"#ff88", "", false},
+		{"red", "", false},
+	} {
+		got, err := ParseColor(tc.in)
+		if tc.valid {
+			didNotExpectError(t, err)
+		} else if _, ok := err.(*InvalidColorError); !ok {
+			t.Errorf("expected invalid colour error for %q, got %v", tc.in, err)
+		}
+		if got != tc.want {
+			t.Errorf("got %q for %q want %q", got, tc.in, tc.want)
+		}
+	}
+}
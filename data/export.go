@@ -0,0 +1,155 @@
+package data
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ExportVersion is bumped whenever the layout of Export changes
+const ExportVersion = 1
+
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+type Export struct {
+	Version    int             `json:"version"`
+	ExportedAt string          `json:"exported_at"`
+	Habits     []ExportedHabit `json:"habits"`
+}
+
+type ExportedHabit struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Tags        []string         `json:"tags,omitempty"`
+	CreatedAt   string           `json:"created_at"`
+	Active      bool             `json:"active"`
+	Schedule    ExportedSchedule `json:"schedule"`
+	Target      float64          `json:"target,omitempty"`
+	Unit        string           `json:"unit,omitempty"`
+	RemindAt    string           `json:"remind_at,omitempty"`
+	// Events are the days the habit was archived and restored, oldest first
+	Events      []ExportedEvent      `json:"events,omitempty"`
+	Completions []ExportedCompletion `json:"completions"`
+}
+
+type ExportedEvent struct {
+	Kind       string `json:"kind"`
+	Date       string `json:"date"`
+	KeepStreak bool   `json:"keep_streak,omitempty"`
+}
+
+type ExportedSchedule struct {
+	Kind     ScheduleKind `json:"kind"`
+	Weekdays int          `json:"weekdays,omitempty"`
+	Count    int          `json:"count,omitempty"`
+}
+
+type ExportedCompletion struct {
+	Date   string  `json:"date"`
+	Streak int     `json:"streak"`
+	Amount float64 `json:"amount,omitempty"`
+	Note   string  `json:"note,omitempty"`
+}
+
+type UnknownFormatError struct {
+	Format string
+}
+
+func (e *UnknownFormatError) Error() string {
+	return fmt.Sprintf("Unknown export format %q, expected json or csv", e.Format)
+}
+
+// Export collects every habit, archived ones included, with its completions
+func (d *Database) Export() (Export, error) {
+	export := Export{
+		Version:    ExportVersion,
+		ExportedAt: d.Now().Format(time.RFC3339),
+		Habits:     []ExportedHabit{},
+	}
+
+	var habits []Habit
+	err := d.DB.Preload("Records", func(db *gorm.DB) *gorm.DB {
+		return db.Order("recorded_at")
+	}).Preload("Events", func(db *gorm.DB) *gorm.DB {
+		return db.Order("day, id")
+	}).Preload("Tags").Order("id").Find(&habits).Error
+	if err != nil {
+		return export, err
+	}
+
+	for _, h := range habits {
+		exported := ExportedHabit{
+			Name:        h.Name,
+			Description: h.Description,
+			Tags:        h.TagNames(),
+			CreatedAt:   h.CreatedOn.String(),
+			Active:      h.Active,
+			Schedule: ExportedSchedule{
+				Kind:     h.Schedule.Kind,
+				Weekdays: h.Schedule.Weekdays,
+				Count:    h.Schedule.Count,
+			},
+			Target:      h.Target,
+			Unit:        h.Unit,
+			RemindAt:    h.RemindAt,
+			Completions: make([]ExportedCompletion, len(h.Records)),
+		}
+		for _, e := range h.Events {
+			exported.Events = append(exported.Events, ExportedEvent{
+				Kind:       e.Kind,
+				Date:       e.Day.String(),
+				KeepStreak: e.KeepStreak,
+			})
+		}
+		for i, c := range h.Records {
+			exported.Completions[i] = ExportedCompletion{
+				Date:   c.RecordedAt.String(),
+				Streak: c.Streak,
+				Amount: c.Amount,
+				Note:   c.Note,
+			}
+		}
+		export.Habits = append(export.Habits, exported)
+	}
+	return export, nil
+}
+
+// WriteExport writes all habits and completions to w in the given format
+func (d *Database) WriteExport(w io.Writer, format string) error {
+	if format != FormatJSON && format != FormatCSV {
+		return &UnknownFormatError{Format: format}
+	}
+
+	export, err := d.Export()
+	if err != nil {
+		return err
+	}
+
+	if format == FormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(export)
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"habit", "date", "streak", "note"}); err != nil {
+		return err
+	}
+	for _, h := range export.Habits {
+		for _, c := range h.Completions {
+			if err := cw.Write([]string{h.Name, c.Date, strconv.Itoa(c.Streak), c.Note}); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
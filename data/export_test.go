@@ -0,0 +1,73 @@
+package data
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestExport(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	export, err := g.Export()
+	didNotExpectError(t, err)
+
+	if export.Version != ExportVersion {
+		t.Errorf("got version %d want %d", export.Version, ExportVersion)
+	}
+
+	// archived habits are exported too
+	if len(export.Habits) != 5 {
+		t.Errorf("got %d habits want %d", len(export.Habits), 5)
+	}
+
+	read := export.Habits[1]
+	if read.Name != "read" || len(read.Completions) != 3 {
+		t.Fatalf("got %s with %d completions want read with 3", read.Name, len(read.Completions))
+	}
+	if read.Completions[0].Date > read.Completions[1].Date {
+		t.Errorf("expected completions in date order, got %v", read.Completions)
+	}
+}
+
+func TestWriteExport(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	t.Run("writes json", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := g.WriteExport(&buf, FormatJSON)
+		didNotExpectError(t, err)
+
+		var export Export
+		err = json.Unmarshal(buf.Bytes(), &export)
+		didNotExpectError(t, err)
+		if len(export.Habits) != 5 {
+			t.Errorf("got %d habits want %d", len(export.Habits), 5)
+		}
+	})
+
+	t.Run("writes one csv row per completion", func(t *testing.T) {
+		var buf bytes.Buffer
+		err := g.WriteExport(&buf, FormatCSV)
+		didNotExpectError(t, err)
+
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if lines[0] != "habit,date,streak,note" {
+			t.Errorf("got header %q", lines[0])
+		}
+		// header plus the 8 seeded completions
+		if len(lines) != 9 {
+			t.Errorf("got %d lines want %d", len(lines), 9)
+		}
+	})
+
+	t.Run("rejects unknown formats", func(t *testing.T) {
+		err := g.WriteExport(&bytes.Buffer{}, "xml")
+		if _, ok := err.(*UnknownFormatError); !ok {
+			t.Errorf("expected unknown format error, got %v", err)
+		}
+	})
+}
@@ -1,7 +1,6 @@
 package data
 
 import (
-	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -26,44 +25,119 @@ var MonthToIntMap = map[string]int{
 
 type Habit struct {
 	gorm.Model
-	Name      string `gorm:"unique;not null"`
-	CreatedAt string
+	Name        string `gorm:"unique;not null"`
+	Description string
+	// Color is a hex colour like "#ff8800" or an ANSI colour number, empty for
+	// the default colour
+	Color     string
+	CreatedOn Date
 	Active    bool
-	Records   []Completion
+	Schedule  Schedule `gorm:"embedded;embeddedPrefix:schedule_"`
+	// Target is the amount needed per day for quantitative habits, zero for
+	// habits that are simply done or not done
+	Target float64
+	Unit   string
+	// RemindAt is the time of day, like "08:30", to be reminded of the habit
+	// if it isn't done yet, empty for no reminder
+	RemindAt string
+	Records  []Completion
+	// Events are the times the habit was archived and restored
+	Events []HabitEvent
+	Tags   []Tag `gorm:"many2many:habit_tags"`
 }
 
 type Completion struct {
 	gorm.Model
-	RecordedAt string
+	RecordedAt Date `gorm:"index"`
 	Streak     int
 	HabitID    uint
+	Amount     float64
+	// Note is free text about how it went, e.g. "ran 5k, knee sore"
+	Note string
 }
 
-type Database struct {
-	DB *gorm.DB
+func (h Habit) IsQuantitative() bool {
+	return h.Target > 0
+}
+
+// TrackedOn reports whether h was being tracked on day, i.e. it had been
+// created and was not archived. Habits archived before the day was recorded
+// count as tracked. It needs the habit's Events to be loaded.
+func (h Habit) TrackedOn(day Date) bool {
+	if !h.CreatedOn.IsZero() && day.Before(h.CreatedOn) {
+		return false
+	}
+	return !h.PausedOn(day)
 }
 
-func currentDate() string {
-	return time.Now().Format("2006-01-02")
+// met reports whether the completion counts towards the streak of h
+func (h Habit) met(c Completion) bool {
+	return !h.IsQuantitative() || c.Amount >= h.Target
 }
 
-func yesterdaysDate() string {
-	return time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+type Database struct {
+	DB       *gorm.DB
+	Calendar Calendar
+	// Clock defaults to SystemClock when nil
+	Clock Clock
 }
 
 func NewHabit(name string) *Habit {
 	return &Habit{
-		Name:   name,
-		Active: true,
+		Name:     name,
+		Active:   true,
+		Schedule: DailySchedule(),
 	}
 }
 
 func (d *Database) CreateHabit(name string) (Habit, error) {
-	hab := Habit{Name: name, CreatedAt: currentDate(), Active: true}
-	if err := d.DB.Create(&hab).Error; err != nil {
-		return hab, err
+	return d.CreateHabitWithSchedule(name, DailySchedule())
+}
+
+func (d *Database) CreateHabitWithSchedule(name string, schedule Schedule) (Habit, error) {
+	hab := Habit{Name: name, CreatedOn: d.Today(), Active: true, Schedule: schedule}
+	return d.createHabit(hab, nil)
+}
+
+// CreateTaggedHabit starts tracking a habit with the name, description,
+// colour, schedule, target, unit and reminder of hab, tagged with tags. Either
+// all of it is saved or, on error, nothing is.
+func (d *Database) CreateTaggedHabit(hab Habit, tags []string) (Habit, error) {
+	color, err := ParseColor(hab.Color)
+	if err != nil {
+		return Habit{}, err
 	}
-	return hab, nil
+	remindAt, err := ParseReminder(hab.RemindAt)
+	if err != nil {
+		return Habit{}, err
+	}
+	if hab.Schedule.Kind == "" {
+		hab.Schedule = DailySchedule()
+	}
+	return d.createHabit(Habit{
+		Name:        hab.Name,
+		Description: strings.TrimSpace(hab.Description),
+		Color:       color,
+		CreatedOn:   d.Today(),
+		Active:      true,
+		Schedule:    hab.Schedule,
+		Target:      hab.Target,
+		Unit:        hab.Unit,
+		RemindAt:    remindAt,
+	}, tags)
+}
+
+func (d *Database) createHabit(hab Habit, tags []string) (Habit, error) {
+	err := d.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&hab).Error; err != nil {
+			return err
+		}
+		if len(tags) == 0 {
+			return nil
+		}
+		return setTags(tx, &hab, tags)
+	})
+	return hab, err
 }
 
 type HabitAndCompletion struct {
@@ -72,57 +146,98 @@ type HabitAndCompletion struct {
 }
 
 func (d *Database) GetActiveHabitsAndCompletions(month, year int) []HabitAndCompletion {
+	return d.GetHabitsAndCompletions(month, year, false, "")
+}
+
+// GetHabitsAndCompletions returns the completions recorded in the month,
+// including those of archived habits if includeArchived is set. A tag limits
+// them to the habits tagged with it.
+func (d *Database) GetHabitsAndCompletions(month, year int, includeArchived bool, tag string) []HabitAndCompletion {
 	var habitsAndStreak []HabitAndCompletion
 
-	firstDayOfMonth, err := time.Parse("2006-01-02", fmt.Sprintf("%d-%02d-01", year, month))
-	if err != nil {
-		fmt.Println(err)
+	firstDayOfMonth := NewDate(year, time.Month(month), 1)
+	lastDayOfMonth := Date{firstDayOfMonth.AddDate(0, 1, -1)}
+
+	query := d.DB.Table("habits").Scopes(taggedWith(tag)).
+		Select("habits.*, completions.*").
+		Joins("INNER JOIN completions ON completions.habit_id=habits.id AND completions.deleted_at IS NULL").
+		Where("habits.deleted_at IS NULL AND completions.recorded_at BETWEEN ? AND ?",
+			firstDayOfMonth, lastDayOfMonth)
+	if !includeArchived {
+		query = query.Where("habits.active = ?", true)
 	}
+	query.Find(&habitsAndStreak)
 
-	lastDayOfMonth, err := time.Parse("2006-01-02", fmt.Sprintf("%d-%02d-31", year, month))
-	if err != nil {
-		// if the date is out of range, like 31st day of month that doesn't have the 31st date
-		lastDayOfMonth, err = time.Parse("2006-01-02", fmt.Sprintf("%d-%02d-30", year, month))
-		if err != nil {
-			// if there is no 30th day, like Feb
-			lastDayOfMonth, err = time.Parse("2006-01-02", fmt.Sprintf("%d-%02d-29", year, month))
-			if err != nil {
-				// if it's not a leap year
-				lastDayOfMonth, err = time.Parse("2006-01-02", fmt.Sprintf("%d-%02d-28", year, month))
-			}
+	return habitsAndStreak
+}
+
+// GetHabitsActiveBetween returns the habits that were being tracked at some
+// point from the day from through the day to, or have completions then,
+// with their Events. Archived habits are only included if includeArchived is
+// set, and a tag limits them to the habits tagged with it.
+func (d *Database) GetHabitsActiveBetween(from, to Date, includeArchived bool, tag string) []Habit {
+	var habits []habitInWindow
+
+	hasCompletions := d.DB.Table("completions").Select("habit_id").
+		Where("deleted_at IS NULL AND recorded_at BETWEEN ? AND ?", from, to)
+
+	query := d.DB.Preload("Events").Scopes(taggedWith(tag)).
+		Select("habits.*, habits.id IN (?) AS has_completions", hasCompletions).
+		Where(d.DB.Where("created_on IS NULL OR created_on <= ?", to).Or("id IN (?)", hasCompletions))
+	if !includeArchived {
+		query = query.Where("active = ?", true)
+	}
+	query.Order("id").Find(&habits)
+
+	var tracked []Habit
+	for _, h := range habits {
+		if h.HasCompletions || h.trackedBetween(from, to) {
+			tracked = append(tracked, h.Habit)
 		}
 	}
+	return tracked
+}
 
-	d.DB.Table("habits").
-		Select("habits.*, completions.*").
-		Joins("INNER JOIN completions ON completions.habit_id=habits.id").
-		Where("habits.active = ? AND completions.recorded_at BETWEEN ? AND ?",
-			true, firstDayOfMonth, lastDayOfMonth).
-		Find(&habitsAndStreak)
+// habitInWindow is a habit and whether it has completions in the days asked
+// for
+type habitInWindow struct {
+	Habit
+	HasCompletions bool
+}
 
-	return habitsAndStreak
+func (habitInWindow) TableName() string {
+	return "habits"
+}
+
+func (h Habit) trackedBetween(from, to Date) bool {
+	for day := from; !day.After(to); day = day.AddDays(1) {
+		if h.TrackedOn(day) {
+			return true
+		}
+	}
+	return false
 }
 
 func (d *Database) GetAvailableYears() []string {
 	var years []string
-	d.DB.Raw("SELECT DISTINCT STRFTIME('%Y', recorded_at) FROM completions").Scan(&years)
+	d.DB.Raw("SELECT DISTINCT STRFTIME('%Y', recorded_at) FROM completions WHERE deleted_at IS NULL").Scan(&years)
 	return years
 }
 
-func (d *Database) getHabits(activeFlag bool) []Habit {
+func (d *Database) getHabits(activeFlag bool, tag string) []Habit {
 	var habits []Habit
-	d.DB.Where("active = ?", activeFlag).Find(&habits)
+	d.DB.Preload("Tags").Scopes(taggedWith(tag)).Where("active = ?", activeFlag).Find(&habits)
 
 	return habits
 
 }
 
 func (d *Database) GetActiveHabits() []Habit {
-	return d.getHabits(true)
+	return d.getHabits(true, "")
 }
 
 func (d *Database) GetInactiveHabits() []Habit {
-	return d.getHabits(false)
+	return d.getHabits(false, "")
 }
 
 func (d *Database) GetAllHabits() []Habit {
@@ -131,6 +246,13 @@ func (d *Database) GetAllHabits() []Habit {
 	return habits
 }
 
+// GetHabit returns habit with its Tags
+func (d *Database) GetHabit(habit string) (Habit, error) {
+	var h Habit
+	err := d.DB.Preload("Tags").Where("name = ?", habit).First(&h).Error
+	return h, err
+}
+
 func (d *Database) getHabitByName(habit string) (Habit, error) {
 	var h Habit
 	if err := d.DB.Where("name = ?", habit).First(&h).Error; err != nil {
@@ -145,12 +267,12 @@ type Result struct {
 	Streak int
 }
 
-func (d *Database) getCompletionAtTime(habit, day string) (Result, error) {
+func (d *Database) getCompletionAtTime(habit string, day Date) (Result, error) {
 	// Check if the last record for this habitId was the day before
 	var result Result
 	err := d.DB.Table("habits").
 		Select("habits.name, habits.id, completions.streak").
-		Joins("inner join completions on completions.habit_id = habits.id").
+		Joins("inner join completions on completions.habit_id = habits.id AND completions.deleted_at IS NULL").
 		Where("habits.name = ? AND completions.recorded_at = ? AND habits.active = true",
 			habit, day).First(&result).Error
 	if err != nil {
@@ -167,61 +289,370 @@ func (e *AlreadyRecordedTodayError) Error() string {
 
 func (d *Database) RecordCompletion(habit string) (Completion, error) {
 	// don't allow more completions if completion already recorded today
-	_, err := d.getCompletionAtTime(habit, currentDate())
+	_, err := d.getCompletionAtTime(habit, d.Today())
 	if err == nil {
 		return Completion{}, &AlreadyRecordedTodayError{}
 	}
 
-	var streak int
+	return d.recordCompletion(habit, d.Today())
+}
+
+// ToggleCompletion records today's completion of habit, or removes it if
+// habit was already done today, rolling its streak back. A quantitative habit
+// that is only partly done today has its amount topped up to the target
+// instead. It returns the completion and whether it was recorded rather than
+// removed.
+func (d *Database) ToggleCompletion(habit string) (Completion, bool, error) {
+	today := d.Today()
+	if _, err := d.getCompletionAtTime(habit, today); err != nil {
+		completion, err := d.recordCompletion(habit, today)
+		return completion, err == nil, err
+	}
 
-	// if a recorded completion from yesterday is not found, streak starts over
-	result, err := d.getCompletionAtTime(habit, yesterdaysDate())
+	h, err := d.getHabitByName(habit)
+	if err != nil {
+		return Completion{}, false, err
+	}
+	var completion Completion
+	err = d.DB.Where("habit_id = ? AND recorded_at = ?", h.ID, today).First(&completion).Error
 	if err != nil {
-		// if the record is not found, streak starts over
-		if strings.Contains("record not found", err.Error()) {
-			streak = 1
+		return completion, false, err
+	}
+	if !h.met(completion) {
+		completion, err := d.RecordAmountOn(habit, today.Time, h.Target-completion.Amount)
+		return completion, err == nil, err
+	}
+	return completion, false, d.DeleteCompletion(habit, today.Time)
+}
 
-			// find the habit id
-			var h Habit
-			err := d.DB.Table("habits").Where("name = ? AND active=true", habit, yesterdaysDate()).First(&h).Error
+// CheckOff is a habit checked off by RecordCompletions and the amount that
+// was added to reach its target, zero for habits without one
+type CheckOff struct {
+	Habit  string
+	Amount float64
+}
 
+// RecordCompletions checks off each of habits for today at once, skipping
+// those that were already done today. Quantitative habits have their amount
+// topped up to the target. Either all of them are recorded or, if one fails,
+// none. It returns the habits it checked off.
+func (d *Database) RecordCompletions(habits []string) ([]CheckOff, error) {
+	var recorded []CheckOff
+	err := d.DB.Transaction(func(tx *gorm.DB) error {
+		txd := Database{DB: tx, Calendar: d.Calendar, Clock: d.Clock}
+		for _, habit := range habits {
+			checkOff, done, err := txd.checkOff(habit)
 			if err != nil {
-				if errors.Is(err, gorm.ErrRecordNotFound) {
-					return Completion{}, err
-				}
+				return fmt.Errorf("%s: %w", habit, err)
 			}
-			result.ID = h.ID
+			if !done {
+				recorded = append(recorded, checkOff)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return recorded, nil
+}
+
+// checkOff records today's completion of habit unless it was already done
+// today, which it reports
+func (d *Database) checkOff(habit string) (CheckOff, bool, error) {
+	h, err := d.getActiveHabitByName(habit)
+	if err != nil {
+		return CheckOff{}, false, err
+	}
+	today := d.Today()
+
+	if !h.IsQuantitative() {
+		_, err := d.RecordCompletion(habit)
+		if _, ok := err.(*AlreadyRecordedTodayError); ok {
+			return CheckOff{}, true, nil
 		}
-	} else {
-		streak = result.Streak + 1
+		return CheckOff{Habit: habit}, false, err
+	}
+
+	var done float64
+	err = d.DB.Model(&Completion{}).
+		Where("habit_id = ? AND recorded_at = ?", h.ID, today).
+		Select("COALESCE(SUM(amount), 0)").Scan(&done).Error
+	if err != nil {
+		return CheckOff{}, false, err
+	}
+	if done >= h.Target {
+		return CheckOff{}, true, nil
+	}
+	missing := h.Target - done
+	if _, err := d.RecordAmountOn(habit, today.Time, missing); err != nil {
+		return CheckOff{}, false, err
+	}
+	return CheckOff{Habit: habit, Amount: missing}, false, nil
+}
+
+type AlreadyRecordedError struct {
+	Date string
+}
+
+func (e *AlreadyRecordedError) Error() string {
+	return fmt.Sprintf("Already recorded completion for %s", e.Date)
+}
+
+type FutureDateError struct {
+	Date string
+}
+
+func (e *FutureDateError) Error() string {
+	return fmt.Sprintf("Cannot record a completion in the future (%s)", e.Date)
+}
+
+// RecordCompletionOn records a completion for habit on an earlier day and
+// recomputes the streaks of any completions recorded after it.
+func (d *Database) RecordCompletionOn(habit string, date time.Time) (Completion, error) {
+	day := DateOf(date)
+	if day.Equal(d.Today()) {
+		return d.RecordCompletion(habit)
+	}
+	if day.After(d.Today()) {
+		return Completion{}, &FutureDateError{Date: day.String()}
+	}
+
+	if _, err := d.getCompletionAtTime(habit, day); err == nil {
+		return Completion{}, &AlreadyRecordedError{Date: day.String()}
+	}
+
+	return d.recordCompletion(habit, day)
+}
+
+// recordCompletion inserts a completion for habit on day and recomputes the
+// streaks from that day onwards according to the habit's schedule.
+func (d *Database) recordCompletion(habit string, day Date) (Completion, error) {
+	h, err := d.getActiveHabitByName(habit)
+	if err != nil {
+		return Completion{}, err
 	}
 
+	// a plain completion of a quantitative habit means the target was reached
 	completion := Completion{
-		RecordedAt: currentDate(),
-		HabitID:    result.ID,
-		Streak:     streak,
+		RecordedAt: day,
+		HabitID:    h.ID,
+		Amount:     h.Target,
+	}
+	err = d.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&completion).Error; err != nil {
+			return err
+		}
+		return recomputeStreaksFrom(tx, h.ID, day)
+	})
+	if err != nil {
+		return Completion{}, err
 	}
-	err = d.DB.Create(&completion).Error
+
+	err = d.DB.First(&completion, completion.ID).Error
 	return completion, err
 }
 
-func (d *Database) ArchiveHabit(habit string) error {
-	err := d.DB.Model(&Habit{}).
-		Where("name = ? AND active = true", habit).
-		Update("active", false).Error
+// DeleteCompletion soft-deletes the completion of habit on date and
+// recomputes the streaks of any completions recorded after it.
+func (d *Database) DeleteCompletion(habit string, date time.Time) error {
+	day := DateOf(date)
+
+	h, err := d.getHabitByName(habit)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	return d.DB.Transaction(func(tx *gorm.DB) error {
+		var completion Completion
+		err := tx.Where("habit_id = ? AND recorded_at = ?", h.ID, day).First(&completion).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Delete(&completion).Error; err != nil {
+			return err
+		}
+		return recomputeStreaksFrom(tx, h.ID, day.AddDays(1))
+	})
 }
 
-func (d *Database) RestoreHabit(habit string) error {
-	err := d.DB.Model(&Habit{}).
-		Where("name = ? AND active = false", habit).
-		Update("active", true).Error
+// SetCompletionNote sets the note of the completion of habit on date. An
+// empty note removes it.
+func (d *Database) SetCompletionNote(habit string, date time.Time, note string) (Completion, error) {
+	h, err := d.getHabitByName(habit)
+	if err != nil {
+		return Completion{}, err
+	}
+
+	var completion Completion
+	err = d.DB.Where("habit_id = ? AND recorded_at = ?", h.ID, DateOf(date)).First(&completion).Error
+	if err != nil {
+		return completion, err
+	}
+	completion.Note = strings.TrimSpace(note)
+	err = d.DB.Model(&completion).Update("note", completion.Note).Error
+	return completion, err
+}
+
+// GetHistory returns every completion of habit, most recent first
+func (d *Database) GetHistory(habit string) ([]Completion, error) {
+	h, err := d.getHabitByName(habit)
+	if err != nil {
+		return nil, err
+	}
+
+	var completions []Completion
+	err = d.DB.Where("habit_id = ?", h.ID).Order("recorded_at desc").Find(&completions).Error
+	return completions, err
+}
+
+func (d *Database) getActiveHabitByName(habit string) (Habit, error) {
+	var h Habit
+	if err := d.DB.Where("name = ? AND active = true", habit).First(&h).Error; err != nil {
+		return h, err
+	}
+	return h, nil
+}
+
+// recomputeStreaksFrom rewrites the streak of every completion of habitID
+// recorded on or after day. The stored streak of the latest completion before
+// day is taken as the starting point so earlier rows are left untouched.
+func recomputeStreaksFrom(tx *gorm.DB, habitID uint, day Date) error {
+	var h Habit
+	if err := tx.Preload("Events").First(&h, habitID).Error; err != nil {
+		return err
+	}
+	neutral := h.neutralPauses()
+
+	var completions []Completion
+	err := tx.Where("habit_id = ?", habitID).
+		Order("recorded_at").
+		Find(&completions).Error
 	if err != nil {
 		return err
 	}
+
+	// only days that met the target count towards streaks and periods
+	var metDates []time.Time
+	for _, c := range completions {
+		if h.met(c) {
+			metDates = append(metDates, c.RecordedAt.Time)
+		}
+	}
+
+	streak := 0
+	var prev time.Time
+	for _, c := range completions {
+		met := h.met(c)
+		if !c.RecordedAt.Before(day) {
+			newStreak := 0
+			if met {
+				if !prev.IsZero() && h.Schedule.continuesStreak(prev, c.RecordedAt.Time, metDates, neutral) {
+					streak++
+				} else {
+					streak = 1
+				}
+				newStreak = streak
+			}
+
+			if c.Streak != newStreak {
+				if err := tx.Model(&c).Update("streak", newStreak).Error; err != nil {
+					return err
+				}
+			}
+		} else if met {
+			streak = c.Streak
+		}
+
+		if met {
+			prev = c.RecordedAt.Time
+		}
+	}
 	return nil
+}
+
+// CurrentStreak returns the streak habit is on today. A streak that can still
+// be continued today, e.g. yesterday's for a daily habit, counts as current.
+func (d *Database) CurrentStreak(habit string) (int, error) {
+	var h Habit
+	if err := d.DB.Preload("Events").Where("name = ?", habit).First(&h).Error; err != nil {
+		return 0, err
+	}
+
+	var completions []Completion
+	err := d.DB.Where("habit_id = ?", h.ID).Order("recorded_at").Find(&completions).Error
+	if err != nil {
+		return 0, err
+	}
+	return currentStreak(h, completions, d.Today()), nil
+}
+
+// currentStreak works out the streak of h on today from its completions,
+// which are ordered by date. The habit's Events need to be loaded.
+func currentStreak(h Habit, completions []Completion, today Date) int {
+	var metDates []time.Time
+	var last Completion
+	for _, c := range completions {
+		if !h.met(c) {
+			continue
+		}
+		metDates = append(metDates, c.RecordedAt.Time)
+		last = c
+	}
+	if len(metDates) == 0 {
+		return 0
+	}
+
+	lastDate := metDates[len(metDates)-1]
+	if lastDate.Equal(today.Time) || h.Schedule.continuesStreak(lastDate, today.Time, metDates, h.neutralPauses()) {
+		return last.Streak
+	}
+	return 0
+}
+
+func (d *Database) ArchiveHabit(habit string) error {
+	return d.ArchiveHabitOn(habit, d.Today().Time)
+}
 
+func (d *Database) RestoreHabit(habit string) error {
+	return d.RestoreHabitOn(habit, d.Today().Time, false)
+}
+
+// DeleteHabit removes habit and its completions. A soft delete only marks
+// them as deleted and renames the habit so its name can be used again, a
+// hard delete removes them and the habit's history for good.
+func (d *Database) DeleteHabit(habit string, hard bool) error {
+	return d.DB.Transaction(func(tx *gorm.DB) error {
+		var h Habit
+		if err := tx.Where("name = ?", habit).First(&h).Error; err != nil {
+			return err
+		}
+
+		if !hard {
+			err := tx.Where("habit_id = ?", h.ID).Delete(&Completion{}).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Model(&h).Update("name", fmt.Sprintf("%s (deleted #%d)", h.Name, h.ID)).Error
+			if err != nil {
+				return err
+			}
+			return tx.Delete(&h).Error
+		}
+
+		err := tx.Unscoped().Where("habit_id = ?", h.ID).Delete(&Completion{}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("habit_id = ?", h.ID).Delete(&HabitEvent{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("habit_id = ?", h.ID).Delete(&habitTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(&h).Error; err != nil {
+			return err
+		}
+		return deleteUnusedTags(tx)
+	})
 }
@@ -1,7 +1,9 @@
 package data
 
 import (
+	"errors"
 	"log"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -13,7 +15,7 @@ import (
 
 func TestCreateHabit(t *testing.T) {
 	db := setup(t)
-	g := Database{DB: db}
+	g := Database{DB: db, Clock: testClock}
 
 	t.Run("creates a new habit", func(t *testing.T) {
 		wantName := "eat"
@@ -24,14 +26,14 @@ func TestCreateHabit(t *testing.T) {
 		db.First(&habit, hab.ID)
 
 		gotName := habit.Name
-		gotCreatedAt := habit.CreatedAt
+		gotCreatedOn := habit.CreatedOn
 		gotActive := habit.Active
 
 		if gotName != wantName {
 			t.Errorf("got %v want %v", gotName, wantName)
 		}
 
-		assertDate(t, gotCreatedAt)
+		assertDate(t, gotCreatedOn)
 
 		if gotActive != true {
 			t.Errorf("got %v want %v", gotActive, true)
@@ -49,7 +51,7 @@ func TestCreateHabit(t *testing.T) {
 
 func TestGetActiveHabits(t *testing.T) {
 	db := setup(t)
-	g := Database{DB: db}
+	g := Database{DB: db, Clock: testClock}
 
 	habits := g.GetActiveHabits()
 
@@ -62,7 +64,7 @@ func TestGetActiveHabits(t *testing.T) {
 
 func TestGetInactiveHabits(t *testing.T) {
 	db := setup(t)
-	g := Database{DB: db}
+	g := Database{DB: db, Clock: testClock}
 
 	habits := g.GetInactiveHabits()
 	want := 1
@@ -73,7 +75,7 @@ func TestGetInactiveHabits(t *testing.T) {
 
 func TestGetAllHabits(t *testing.T) {
 	db := setup(t)
-	g := Database{DB: db}
+	g := Database{DB: db, Clock: testClock}
 
 	habits := g.GetAllHabits()
 	want := 5
@@ -84,7 +86,7 @@ func TestGetAllHabits(t *testing.T) {
 
 func TestRecordCompletion(t *testing.T) {
 	db := setup(t)
-	g := Database{DB: db}
+	g := Database{DB: db, Clock: testClock}
 
 	t.Run("adds to streak if a completion was recorded yesterday", func(t *testing.T) {
 		// id 1 is cook
@@ -131,9 +133,123 @@ func TestRecordCompletion(t *testing.T) {
 
 }
 
+func TestRecordCompletionOn(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	t.Run("continues the streak of the day before", func(t *testing.T) {
+		got, err := g.RecordCompletionOn("read", testNow.AddDate(0, 0, -1))
+		didNotExpectError(t, err)
+
+		wantStreak := 5
+		if got.Streak != wantStreak {
+			t.Errorf("got %d want %d", got.Streak, wantStreak)
+		}
+	})
+
+	t.Run("recomputes the streaks of later completions", func(t *testing.T) {
+		got, err := g.RecordCompletionOn("garden", testNow.AddDate(0, 0, -2))
+		didNotExpectError(t, err)
+
+		if got.Streak != 1 {
+			t.Errorf("got %d want %d", got.Streak, 1)
+		}
+
+		var later Completion
+		db.Where("habit_id = ? AND recorded_at = ?", 4, yesterday()).First(&later)
+		if later.Streak != 2 {
+			t.Errorf("got %d want %d", later.Streak, 2)
+		}
+	})
+
+	t.Run("does not record a day twice", func(t *testing.T) {
+		_, err := g.RecordCompletionOn("cook", testNow.AddDate(0, 0, -1))
+		if _, ok := err.(*AlreadyRecordedError); !ok {
+			t.Errorf("expected already recorded error, got %v", err)
+		}
+	})
+
+	t.Run("does not record days in the future", func(t *testing.T) {
+		_, err := g.RecordCompletionOn("cook", testNow.AddDate(0, 0, 1))
+		if _, ok := err.(*FutureDateError); !ok {
+			t.Errorf("expected future date error, got %v", err)
+		}
+	})
+
+	t.Run("does not record completion for inactive habits", func(t *testing.T) {
+		_, err := g.RecordCompletionOn("clean", testNow.AddDate(0, 0, -1))
+		assertRecordNotFound(t, err)
+	})
+}
+
+func TestDeleteCompletion(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	t.Run("deletes a completion and recomputes later streaks", func(t *testing.T) {
+		err := g.DeleteCompletion("read", testNow.AddDate(0, 0, -3))
+		didNotExpectError(t, err)
+
+		_, err = g.getCompletionAtTime("read", DateOf(testNow.AddDate(0, 0, -3)))
+		assertRecordNotFound(t, err)
+
+		result, err := g.getCompletionAtTime("read", DateOf(testNow.AddDate(0, 0, -2)))
+		didNotExpectError(t, err)
+		if result.Streak != 1 {
+			t.Errorf("got %d want %d", result.Streak, 1)
+		}
+	})
+
+	t.Run("allows recording the day again after undo", func(t *testing.T) {
+		err := g.DeleteCompletion("play guitar", testNow)
+		didNotExpectError(t, err)
+
+		got, err := g.RecordCompletion("play guitar")
+		didNotExpectError(t, err)
+		if got.Streak != 1 {
+			t.Errorf("got %d want %d", got.Streak, 1)
+		}
+	})
+
+	t.Run("returns record not found if there is no completion", func(t *testing.T) {
+		err := g.DeleteCompletion("cook", testNow.AddDate(0, 0, -5))
+		assertRecordNotFound(t, err)
+	})
+}
+
+func TestCurrentStreak(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	cases := []struct {
+		habit string
+		want  int
+	}{
+		// recorded yesterday, can still be continued today
+		{"cook", 3},
+		// last recorded two days ago
+		{"read", 0},
+		// recorded today
+		{"play guitar", 1},
+		// never recorded
+		{"clean", 0},
+	}
+
+	for _, tc := range cases {
+		got, err := g.CurrentStreak(tc.habit)
+		didNotExpectError(t, err)
+		if got != tc.want {
+			t.Errorf("%s: got %d want %d", tc.habit, got, tc.want)
+		}
+	}
+
+	_, err := g.CurrentStreak("NOT EXISTING")
+	assertRecordNotFound(t, err)
+}
+
 func TestGetHabitByName(t *testing.T) {
 	db := setup(t)
-	g := Database{DB: db}
+	g := Database{DB: db, Clock: testClock}
 
 	t.Run("gets habit by name", func(t *testing.T) {
 		want := "cook"
@@ -153,7 +269,7 @@ func TestGetHabitByName(t *testing.T) {
 
 func TestArchiveHabit(t *testing.T) {
 	db := setup(t)
-	g := Database{DB: db}
+	g := Database{DB: db, Clock: testClock}
 
 	t.Run("archives an active habit", func(t *testing.T) {
 		err := g.ArchiveHabit("cook")
@@ -165,6 +281,13 @@ func TestArchiveHabit(t *testing.T) {
 		if habit.Active {
 			t.Errorf("got %v expected false", habit.Active)
 		}
+
+		events, err := g.GetHabitEvents("cook")
+		didNotExpectError(t, err)
+		if len(events) != 1 || events[0].Kind != EventArchived {
+			t.Fatalf("got %+v want one archive event", events)
+		}
+		assertDate(t, events[0].Day)
 	})
 
 	t.Run("cannot archive an inactive habit", func(t *testing.T) {
@@ -179,7 +302,7 @@ func TestArchiveHabit(t *testing.T) {
 
 func TestRestoreHabit(t *testing.T) {
 	db := setup(t)
-	g := Database{DB: db}
+	g := Database{DB: db, Clock: testClock}
 
 	t.Run("restores an inactive habit", func(t *testing.T) {
 		err := g.RestoreHabit("clean")
@@ -194,19 +317,233 @@ func TestRestoreHabit(t *testing.T) {
 		}
 
 	})
+
+	t.Run("records when an archived habit was restored", func(t *testing.T) {
+		didNotExpectError(t, g.ArchiveHabit("cook"))
+		didNotExpectError(t, g.RestoreHabit("cook"))
+
+		habit, _ := g.getHabitByName("cook")
+		if !habit.Active {
+			t.Errorf("got %v expected true", habit.Active)
+		}
+		events, _ := g.GetHabitEvents("cook")
+		if len(events) != 2 || events[1].Kind != EventRestored || !events[1].Day.Equal(today()) {
+			t.Errorf("got %+v want an archive and a restore event today", events)
+		}
+	})
+}
+
+func TestToggleCompletion(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	completion, recorded, err := g.ToggleCompletion("cook")
+	didNotExpectError(t, err)
+	if !recorded || completion.Streak != 4 {
+		t.Errorf("got recorded %v with streak %d want a recorded streak of %d", recorded, completion.Streak, 4)
+	}
+
+	_, recorded, err = g.ToggleCompletion("cook")
+	didNotExpectError(t, err)
+	if recorded {
+		t.Errorf("expected the second toggle to remove the completion")
+	}
+	_, err = g.getCompletionAtTime("cook", today())
+	assertRecordNotFound(t, err)
+
+	// back to the streak of yesterday
+	streak, err := g.CurrentStreak("cook")
+	didNotExpectError(t, err)
+	if streak != 3 {
+		t.Errorf("got streak %d want %d", streak, 3)
+	}
+
+	t.Run("tops up a partly done quantitative habit", func(t *testing.T) {
+		_, err := g.CreateQuantitativeHabit("drink water", DailySchedule(), 8, "glasses")
+		didNotExpectError(t, err)
+		_, err = g.RecordAmount("drink water", 3)
+		didNotExpectError(t, err)
+
+		completion, recorded, err := g.ToggleCompletion("drink water")
+		didNotExpectError(t, err)
+		if !recorded || completion.Amount != 8 || completion.Streak != 1 {
+			t.Errorf("got recorded %v with amount %v and streak %d want a recorded amount of %v and streak %d",
+				recorded, completion.Amount, completion.Streak, 8.0, 1)
+		}
+
+		// once the target is met it is removed like any other completion
+		_, recorded, err = g.ToggleCompletion("drink water")
+		didNotExpectError(t, err)
+		if recorded {
+			t.Errorf("expected toggling a met target to remove the completion")
+		}
+		_, err = g.getCompletionAtTime("drink water", today())
+		assertRecordNotFound(t, err)
+	})
+}
+
+func TestRecordCompletions(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	t.Run("records several habits and skips those done today", func(t *testing.T) {
+		recorded, err := g.RecordCompletions([]string{"cook", "play guitar", "garden"})
+		didNotExpectError(t, err)
+		want := []CheckOff{{Habit: "cook"}, {Habit: "garden"}}
+		if !reflect.DeepEqual(recorded, want) {
+			t.Errorf("got recorded %v want %v", recorded, want)
+		}
+		result, err := g.getCompletionAtTime("garden", today())
+		didNotExpectError(t, err)
+		if result.Streak != 511 {
+			t.Errorf("got streak %d want %d", result.Streak, 511)
+		}
+	})
+
+	t.Run("tops up quantitative habits partly done today", func(t *testing.T) {
+		for habit, done := range map[string]float64{"drink water": 3, "run": 5} {
+			_, err := g.CreateQuantitativeHabit(habit, DailySchedule(), 5, "")
+			didNotExpectError(t, err)
+			_, err = g.RecordAmount(habit, done)
+			didNotExpectError(t, err)
+		}
+
+		recorded, err := g.RecordCompletions([]string{"drink water", "run", "play guitar"})
+		didNotExpectError(t, err)
+		want := []CheckOff{{Habit: "drink water", Amount: 2}}
+		if !reflect.DeepEqual(recorded, want) {
+			t.Errorf("got recorded %v want %v", recorded, want)
+		}
+
+		summaries, err := g.GetSummaries("")
+		didNotExpectError(t, err)
+		if !summaries["drink water"].DoneToday() || summaries["drink water"].CurrentStreak != 1 {
+			t.Errorf("got %+v want drink water done today", summaries["drink water"])
+		}
+	})
+
+	t.Run("records nothing when everything was done today", func(t *testing.T) {
+		recorded, err := g.RecordCompletions([]string{"cook", "drink water"})
+		didNotExpectError(t, err)
+		if len(recorded) != 0 {
+			t.Errorf("got recorded %v want nothing", recorded)
+		}
+	})
+
+	t.Run("records none if one fails", func(t *testing.T) {
+		_, err := g.RecordCompletions([]string{"read", "fly"})
+		assertRecordNotFound(t, errors.Unwrap(err))
+
+		_, err = g.getCompletionAtTime("read", today())
+		assertRecordNotFound(t, err)
+	})
+}
+
+func TestSetCompletionNote(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	t.Run("adds a note to a completion", func(t *testing.T) {
+		_, err := g.RecordCompletion("cook")
+		didNotExpectError(t, err)
+
+		completion, err := g.SetCompletionNote("cook", testNow, "  pasta, too salty ")
+		didNotExpectError(t, err)
+		if completion.Note != "pasta, too salty" {
+			t.Errorf("got note %q want %q", completion.Note, "pasta, too salty")
+		}
+
+		history, err := g.GetHistory("cook")
+		didNotExpectError(t, err)
+		if history[0].Note != "pasta, too salty" {
+			t.Errorf("got note %q want %q", history[0].Note, "pasta, too salty")
+		}
+	})
+
+	t.Run("returns an error without a completion on that day", func(t *testing.T) {
+		_, err := g.SetCompletionNote("garden", testNow, "weeds")
+		assertRecordNotFound(t, err)
+	})
+}
+
+func TestGetHistory(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	history, err := g.GetHistory("read")
+	didNotExpectError(t, err)
+	if len(history) != 3 {
+		t.Fatalf("got %d completions want %d", len(history), 3)
+	}
+	for i := 1; i < len(history); i++ {
+		if history[i].RecordedAt.After(history[i-1].RecordedAt) {
+			t.Errorf("expected the most recent completion first, got %v", history)
+		}
+	}
+
+	_, err = g.GetHistory("fly")
+	assertRecordNotFound(t, err)
+}
+
+func TestDeleteHabit(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	countCompletions := func(habitID uint) (int64, int64) {
+		var kept, all int64
+		db.Model(&Completion{}).Where("habit_id = ?", habitID).Count(&kept)
+		db.Unscoped().Model(&Completion{}).Where("habit_id = ?", habitID).Count(&all)
+		return kept, all
+	}
+
+	t.Run("soft deletes a habit and frees its name", func(t *testing.T) {
+		read, _ := g.GetHabit("read")
+		didNotExpectError(t, g.DeleteHabit("read", false))
+
+		_, err := g.GetHabit("read")
+		assertRecordNotFound(t, err)
+		if kept, all := countCompletions(read.ID); kept != 0 || all != 3 {
+			t.Errorf("got %d completions kept and %d in total want 0 and 3", kept, all)
+		}
+
+		_, err = g.CreateHabit("read")
+		didNotExpectError(t, err)
+	})
+
+	t.Run("hard deletes a habit with its completions and history", func(t *testing.T) {
+		didNotExpectError(t, g.ArchiveHabit("play guitar"))
+		guitar, _ := g.GetHabit("play guitar")
+		didNotExpectError(t, g.DeleteHabit("play guitar", true))
+
+		var habits int64
+		db.Unscoped().Model(&Habit{}).Where("id = ?", guitar.ID).Count(&habits)
+		if kept, all := countCompletions(guitar.ID); habits != 0 || all != 0 || kept != 0 {
+			t.Errorf("got %d habits and %d completions left want none", habits, all)
+		}
+		var events int64
+		db.Model(&HabitEvent{}).Where("habit_id = ?", guitar.ID).Count(&events)
+		if events != 0 {
+			t.Errorf("got %d events left want none", events)
+		}
+	})
+
+	t.Run("returns an error for unknown habits", func(t *testing.T) {
+		err := g.DeleteHabit("fly", true)
+		assertRecordNotFound(t, err)
+	})
 }
 
 func TestGetActiveHabitsAndCompletions(t *testing.T) {
 	db := setup(t)
-	g := Database{DB: db}
+	g := Database{DB: db, Clock: testClock}
 
 	t.Run("gets active habits and their completions", func(t *testing.T) {
 		type Result struct {
 			name       string
-			recordedAt string
+			recordedAt Date
 		}
 
-		year, month, _ := time.Now().Date()
+		year, month, _ := testNow.Date()
 
 		habitsAndCompletions := g.GetActiveHabitsAndCompletions(int(month), year)
 
@@ -233,8 +570,8 @@ func TestGetActiveHabitsAndCompletions(t *testing.T) {
 	})
 
 	t.Run("returns empty slice if nothing there", func(t *testing.T) {
-		month := time.Now().AddDate(0, 1, 0).Month().String()[0:3]
-		year := time.Now().AddDate(1, 0, 0).Year()
+		month := testNow.AddDate(0, 1, 0).Month().String()[0:3]
+		year := testNow.AddDate(1, 0, 0).Year()
 
 		h := g.GetActiveHabitsAndCompletions(MonthToIntMap[month], year)
 		if len(h) != 0 {
@@ -243,9 +580,46 @@ func TestGetActiveHabitsAndCompletions(t *testing.T) {
 	})
 }
 
+func TestGetHabitsActiveBetween(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	db.Model(&Habit{}).Where("name IN ?", []string{"cook", "read"}).Update("created_on", today().AddDays(-40))
+	didNotExpectError(t, g.ArchiveHabitOn("cook", today().AddDays(-10).Time))
+
+	names := func(habits []Habit) string {
+		var names []string
+		for _, h := range habits {
+			names = append(names, h.Name)
+		}
+		return strings.Join(names, ",")
+	}
+
+	for _, tc := range []struct {
+		name            string
+		from, to        Date
+		includeArchived bool
+		want            string
+	}{
+		{"active habits only", today().AddDays(-30), today(), false, "read,garden,play guitar"},
+		// clean was archived before archive days were recorded
+		{"with archived habits", today().AddDays(-30), today(), true, "cook,read,clean,garden,play guitar"},
+		{"archived before the period", today().AddDays(-5), today().AddDays(-2), true, "read"},
+		{"before most habits were created", today().AddDays(-50), today().AddDays(-31), true, "cook,read"},
+		{"habits with completions before their creation", today().AddDays(-800), today().AddDays(-700), false, "play guitar"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := names(g.GetHabitsActiveBetween(tc.from, tc.to, tc.includeArchived, ""))
+			if got != tc.want {
+				t.Errorf("got %s want %s", got, tc.want)
+			}
+		})
+	}
+}
+
 func TestGetAvailableYears(t *testing.T) {
 	db := setup(t)
-	g := Database{DB: db}
+	g := Database{DB: db, Clock: testClock}
 
 	years := g.GetAvailableYears()
 	if len(years) != 3 {
@@ -261,11 +635,11 @@ func setup(t *testing.T) *gorm.DB {
 		log.Fatalf("unable to open in-memory SQLite DB: %v", err)
 	}
 
-	db.AutoMigrate(&Habit{}, &Completion{})
+	db.AutoMigrate(&Habit{}, &Completion{}, &HabitEvent{}, &Tag{}, &habitTag{})
 
 	seedHabits(db)
 	t.Cleanup(func() {
-		db.Migrator().DropTable(&Habit{}, &Completion{})
+		db.Migrator().DropTable(&Habit{}, &Completion{}, &HabitEvent{}, &Tag{}, &habitTag{})
 	})
 	return db
 }
@@ -273,28 +647,28 @@ func setup(t *testing.T) *gorm.DB {
 func seedHabits(db *gorm.DB) {
 
 	habits := []Habit{
-		{Name: "cook", CreatedAt: currentDate(), Active: true},
-		{Name: "read", CreatedAt: currentDate(), Active: true},
-		{Name: "clean", CreatedAt: currentDate(), Active: false},
-		{Name: "garden", CreatedAt: currentDate(), Active: true},
-		{Name: "play guitar", CreatedAt: currentDate(), Active: true},
+		{Name: "cook", CreatedOn: today(), Active: true},
+		{Name: "read", CreatedOn: today(), Active: true},
+		{Name: "clean", CreatedOn: today(), Active: false},
+		{Name: "garden", CreatedOn: today(), Active: true},
+		{Name: "play guitar", CreatedOn: today(), Active: true},
 	}
 
 	records := []Completion{
-		{RecordedAt: yesterdaysDate(), Streak: 3, HabitID: 1},
-		{RecordedAt: time.Now().AddDate(0, 0, -2).Format("2006-01-02"),
+		{RecordedAt: yesterday(), Streak: 3, HabitID: 1},
+		{RecordedAt: DateOf(testNow.AddDate(0, 0, -2)),
 			Streak:  4,
 			HabitID: 2},
-		{RecordedAt: time.Now().AddDate(0, 0, -3).Format("2006-01-02"),
+		{RecordedAt: DateOf(testNow.AddDate(0, 0, -3)),
 			Streak:  3,
 			HabitID: 2},
-		{RecordedAt: time.Now().AddDate(0, 0, -4).Format("2006-01-02"),
+		{RecordedAt: DateOf(testNow.AddDate(0, 0, -4)),
 			Streak:  2,
 			HabitID: 2},
-		{RecordedAt: yesterdaysDate(), Streak: 510, HabitID: 4},
-		{RecordedAt: currentDate(), Streak: 1, HabitID: 5},
-		{RecordedAt: time.Now().AddDate(-2, 0, 0).Format("2006-01-02"), Streak: 20, HabitID: 5},
-		{RecordedAt: time.Now().AddDate(-10, 0, 0).Format("2006-01-02"), Streak: 20, HabitID: 5},
+		{RecordedAt: yesterday(), Streak: 510, HabitID: 4},
+		{RecordedAt: today(), Streak: 1, HabitID: 5},
+		{RecordedAt: DateOf(testNow.AddDate(-2, 0, 0)), Streak: 20, HabitID: 5},
+		{RecordedAt: DateOf(testNow.AddDate(-10, 0, 0)), Streak: 20, HabitID: 5},
 	}
 	for _, i := range habits {
 		db.Create(&i)
@@ -306,11 +680,26 @@ func seedHabits(db *gorm.DB) {
 
 }
 
-func assertDate(t *testing.T, got string) {
+// testNow is the time the tests run at, so that seeds and assertions don't
+// depend on the day the suite happens to run
+var testNow = time.Date(2024, time.March, 15, 12, 0, 0, 0, time.Local)
+
+var testClock = ClockFunc(func() time.Time { return testNow })
+
+// today and yesterday are the days seen by a Database with the zero Calendar
+// and the test clock
+func today() Date {
+	return Calendar{}.DateOf(testNow)
+}
+
+func yesterday() Date {
+	return today().AddDays(-1)
+}
+
+func assertDate(t *testing.T, got Date) {
 	t.Helper()
-	_, err := time.Parse("2006-01-02", got)
-	if err != nil {
-		t.Errorf("got %v, error: %v", got, err)
+	if !got.Equal(today()) {
+		t.Errorf("got %v, want today %v", got, today())
 	}
 
 }
@@ -0,0 +1,39 @@
+package data
+
+import (
+	"time"
+)
+
+// GetYearActivity returns how much was done on each day of year that has any
+// completions, for habit or for all habits combined if habit is empty. A
+// completion that met its target counts as 1, a partial amount towards a
+// target as the share that was done.
+func (d *Database) GetYearActivity(habit string, year int) (map[Date]float64, error) {
+	var rows []struct {
+		RecordedAt Date
+		Amount     float64
+		Target     float64
+	}
+
+	query := d.DB.Table("completions").
+		Select("completions.recorded_at, completions.amount, habits.target").
+		Joins("INNER JOIN habits ON habits.id = completions.habit_id AND habits.deleted_at IS NULL").
+		Where("completions.deleted_at IS NULL AND completions.recorded_at BETWEEN ? AND ?",
+			NewDate(year, time.January, 1), NewDate(year, time.December, 31))
+	if habit != "" {
+		query = query.Where("habits.name = ?", habit)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	activity := map[Date]float64{}
+	for _, r := range rows {
+		done := 1.0
+		if r.Target > 0 && r.Amount < r.Target {
+			done = r.Amount / r.Target
+		}
+		activity[r.RecordedAt] += done
+	}
+	return activity, nil
+}
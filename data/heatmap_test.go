@@ -0,0 +1,46 @@
+package data
+
+import (
+	"testing"
+)
+
+func TestGetYearActivity(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	_, err := g.CreateQuantitativeHabit("drink water", DailySchedule(), 8, "glasses")
+	didNotExpectError(t, err)
+	_, err = g.RecordAmountOn("drink water", today().AddDays(-1).Time, 4)
+	didNotExpectError(t, err)
+
+	t.Run("combines all habits", func(t *testing.T) {
+		got, err := g.GetYearActivity("", today().Year())
+		didNotExpectError(t, err)
+
+		// cook and garden were done yesterday, half the water was drunk
+		if got[yesterday()] != 2.5 {
+			t.Errorf("got %v for yesterday want %v", got[yesterday()], 2.5)
+		}
+		if got[today()] != 1 {
+			t.Errorf("got %v for today want %v", got[today()], 1)
+		}
+	})
+
+	t.Run("restricts to one habit and year", func(t *testing.T) {
+		got, err := g.GetYearActivity("play guitar", today().Year()-2)
+		didNotExpectError(t, err)
+
+		want := DateOf(testNow.AddDate(-2, 0, 0))
+		if len(got) != 1 || got[want] != 1 {
+			t.Errorf("got %v want only %v", got, want)
+		}
+	})
+
+	t.Run("is empty for years without completions", func(t *testing.T) {
+		got, err := g.GetYearActivity("", 1990)
+		didNotExpectError(t, err)
+		if len(got) != 0 {
+			t.Errorf("got %v want no activity", got)
+		}
+	})
+}
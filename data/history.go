@@ -0,0 +1,174 @@
+package data
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	EventArchived = "archived"
+	EventRestored = "restored"
+)
+
+// HabitEvent records that a habit was archived or restored, effective from
+// Day on
+type HabitEvent struct {
+	ID      uint
+	HabitID uint `gorm:"index"`
+	Kind    string
+	Day     Date
+	// KeepStreak makes the pause ending with this restore neutral, so it
+	// doesn't break the streak the habit was on
+	KeepStreak bool
+	CreatedAt  time.Time
+}
+
+type InvalidEventDateError struct {
+	Date   string
+	Reason string
+}
+
+func (e *InvalidEventDateError) Error() string {
+	return fmt.Sprintf("Cannot use %s: %s", e.Date, e.Reason)
+}
+
+// pause is a stretch of days a habit was archived, from the day it was
+// archived up to but not including the day it was restored. An open pause
+// has a zero to.
+type pause struct {
+	from, to Date
+	neutral  bool
+}
+
+func (p pause) covers(day Date) bool {
+	return !day.Before(p.from) && (p.to.IsZero() || day.Before(p.to))
+}
+
+type pauses []pause
+
+// covers reports whether day falls into one of the pauses. It is safe to call
+// on nil.
+func (ps pauses) covers(t time.Time) bool {
+	day := DateOf(t)
+	for _, p := range ps {
+		if p.covers(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// pauses returns every stretch h was archived, ordered by date. It needs the
+// habit's Events to be loaded.
+func (h Habit) pauses() pauses {
+	events := append([]HabitEvent(nil), h.Events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Day.Equal(events[j].Day) {
+			return events[i].ID < events[j].ID
+		}
+		return events[i].Day.Before(events[j].Day)
+	})
+
+	var ps pauses
+	var open *pause
+	for _, e := range events {
+		switch {
+		case e.Kind == EventArchived && open == nil:
+			open = &pause{from: e.Day}
+		case e.Kind == EventRestored && open != nil:
+			open.to = e.Day
+			open.neutral = e.KeepStreak
+			ps = append(ps, *open)
+			open = nil
+		}
+	}
+	if open != nil {
+		ps = append(ps, *open)
+	}
+	return ps
+}
+
+// neutralPauses are the pauses that don't break streaks
+func (h Habit) neutralPauses() pauses {
+	var ps pauses
+	for _, p := range h.pauses() {
+		if p.neutral {
+			ps = append(ps, p)
+		}
+	}
+	return ps
+}
+
+// PausedOn reports whether h was archived on day. It needs the habit's
+// Events to be loaded.
+func (h Habit) PausedOn(day Date) bool {
+	for _, p := range h.pauses() {
+		if p.covers(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// ArchiveHabitOn archives habit with effect from day. Archiving a habit that
+// is already archived does nothing.
+func (d *Database) ArchiveHabitOn(habit string, day time.Time) error {
+	return d.addEvent(habit, HabitEvent{Kind: EventArchived, Day: DateOf(day)})
+}
+
+// RestoreHabitOn restores habit with effect from day. With keepStreak set the
+// days it was archived don't break its streak. Restoring an active habit
+// does nothing.
+func (d *Database) RestoreHabitOn(habit string, day time.Time, keepStreak bool) error {
+	return d.addEvent(habit, HabitEvent{Kind: EventRestored, Day: DateOf(day), KeepStreak: keepStreak})
+}
+
+func (d *Database) addEvent(habit string, event HabitEvent) error {
+	if event.Day.After(d.Today()) {
+		return &InvalidEventDateError{Date: event.Day.String(), Reason: "it is in the future"}
+	}
+
+	return d.DB.Transaction(func(tx *gorm.DB) error {
+		var h Habit
+		if err := tx.Preload("Events").Where("name = ?", habit).First(&h).Error; err != nil {
+			return err
+		}
+		active := event.Kind == EventRestored
+		if h.Active == active {
+			return nil
+		}
+
+		for _, e := range h.Events {
+			if event.Day.Before(e.Day) {
+				return &InvalidEventDateError{
+					Date:   event.Day.String(),
+					Reason: fmt.Sprintf("%s was already %s on %s", habit, e.Kind, e.Day),
+				}
+			}
+		}
+
+		event.HabitID = h.ID
+		if err := tx.Create(&event).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&h).Update("active", active).Error; err != nil {
+			return err
+		}
+		return recomputeStreaksFrom(tx, h.ID, event.Day)
+	})
+}
+
+// GetHabitEvents returns when habit was archived and restored, oldest first
+func (d *Database) GetHabitEvents(habit string) ([]HabitEvent, error) {
+	h, err := d.getHabitByName(habit)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []HabitEvent
+	err = d.DB.Where("habit_id = ?", h.ID).Order("day, id").Find(&events).Error
+	return events, err
+}
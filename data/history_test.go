@@ -0,0 +1,116 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPausesKeepStreaks(t *testing.T) {
+	day := func(n int) time.Time {
+		return today().AddDays(n).Time
+	}
+
+	record := func(t *testing.T, g Database, habit string, days ...int) Completion {
+		t.Helper()
+		var c Completion
+		for _, n := range days {
+			var err error
+			c, err = g.RecordCompletionOn(habit, day(n))
+			didNotExpectError(t, err)
+		}
+		return c
+	}
+
+	t.Run("a neutral pause doesn't break the streak", func(t *testing.T) {
+		db := setup(t)
+		g := Database{DB: db, Clock: testClock}
+		_, err := g.CreateHabit("stretch")
+		didNotExpectError(t, err)
+
+		record(t, g, "stretch", -10, -9, -8)
+		didNotExpectError(t, g.ArchiveHabitOn("stretch", day(-7)))
+		didNotExpectError(t, g.RestoreHabitOn("stretch", day(-3), true))
+
+		got := record(t, g, "stretch", -3)
+		if got.Streak != 4 {
+			t.Errorf("got %d want %d", got.Streak, 4)
+		}
+	})
+
+	t.Run("a plain pause breaks the streak", func(t *testing.T) {
+		db := setup(t)
+		g := Database{DB: db, Clock: testClock}
+		_, err := g.CreateHabit("stretch")
+		didNotExpectError(t, err)
+
+		record(t, g, "stretch", -10, -9, -8)
+		didNotExpectError(t, g.ArchiveHabitOn("stretch", day(-7)))
+		didNotExpectError(t, g.RestoreHabitOn("stretch", day(-3), false))
+
+		got := record(t, g, "stretch", -3)
+		if got.Streak != 1 {
+			t.Errorf("got %d want %d", got.Streak, 1)
+		}
+	})
+
+	t.Run("the streak is current right after a neutral pause", func(t *testing.T) {
+		db := setup(t)
+		g := Database{DB: db, Clock: testClock}
+		_, err := g.CreateHabit("stretch")
+		didNotExpectError(t, err)
+
+		record(t, g, "stretch", -6, -5)
+		didNotExpectError(t, g.ArchiveHabitOn("stretch", day(-4)))
+		didNotExpectError(t, g.RestoreHabitOn("stretch", day(0), true))
+
+		streak, err := g.CurrentStreak("stretch")
+		didNotExpectError(t, err)
+		if streak != 2 {
+			t.Errorf("got %d want %d", streak, 2)
+		}
+	})
+
+	t.Run("weekly habits skip paused weeks", func(t *testing.T) {
+		db := setup(t)
+		// 2024-03-15 is a Friday, so each offset of 7 days is another week
+		g := Database{DB: db, Clock: testClock}
+		weekly, _ := ParseSchedule(ScheduleWeekly, "1")
+		_, err := g.CreateHabitWithSchedule("call home", weekly)
+		didNotExpectError(t, err)
+
+		record(t, g, "call home", -28)
+		didNotExpectError(t, g.ArchiveHabitOn("call home", day(-25)))
+		didNotExpectError(t, g.RestoreHabitOn("call home", day(-4), true))
+
+		got := record(t, g, "call home", -1)
+		if got.Streak != 2 {
+			t.Errorf("got %d want %d", got.Streak, 2)
+		}
+	})
+}
+
+func TestHabitEventDates(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	err := g.ArchiveHabitOn("cook", today().AddDays(1).Time)
+	if _, ok := err.(*InvalidEventDateError); !ok {
+		t.Errorf("expected invalid event date error for a future day, got %v", err)
+	}
+
+	didNotExpectError(t, g.ArchiveHabitOn("cook", today().AddDays(-5).Time))
+	err = g.RestoreHabitOn("cook", today().AddDays(-6).Time, false)
+	if _, ok := err.(*InvalidEventDateError); !ok {
+		t.Errorf("expected invalid event date error before the archive, got %v", err)
+	}
+
+	didNotExpectError(t, g.RestoreHabitOn("cook", today().AddDays(-2).Time, false))
+
+	h := Habit{}
+	db.Preload("Events").Where("name = ?", "cook").First(&h)
+	for n, want := range map[int]bool{-6: false, -5: true, -3: true, -2: false} {
+		if got := h.PausedOn(today().AddDays(n)); got != want {
+			t.Errorf("got paused %v %d days ago want %v", got, -n, want)
+		}
+	}
+}
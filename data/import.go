@@ -0,0 +1,439 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// import formats besides the project's own FormatJSON and FormatCSV
+const (
+	// FormatNameDate is a CSV with a name,date header and one row per completion
+	FormatNameDate = "name-date"
+	// FormatLoop is the Checkmarks.csv of a Loop Habit Tracker export, with a
+	// Date column followed by one column per habit
+	FormatLoop = "loop"
+)
+
+// loopChecked is the value Loop Habit Tracker uses for a manually checked day
+const loopChecked = "2"
+
+type ImportRecord struct {
+	Habit  string  `json:"habit"`
+	Date   string  `json:"date"`
+	Amount float64 `json:"amount,omitempty"`
+}
+
+type ImportConflict struct {
+	ImportRecord
+	// Row is the line of a CSV file or the position of the habit in a JSON
+	// export, counting from 1, for records that can't be told apart otherwise
+	Row    int    `json:"row,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// ImportReport describes what an import did, or would do in a dry run.
+// Duplicates are completions that already exist and are skipped, conflicts
+// are records that cannot be imported as they are and are skipped as well.
+type ImportReport struct {
+	DryRun     bool             `json:"dry_run"`
+	NewHabits  []string         `json:"new_habits"`
+	Imported   int              `json:"imported"`
+	Duplicates []ImportRecord   `json:"duplicates"`
+	Conflicts  []ImportConflict `json:"conflicts"`
+}
+
+type UnsupportedExportVersionError struct {
+	Version int
+}
+
+func (e *UnsupportedExportVersionError) Error() string {
+	return fmt.Sprintf("Unsupported export version %d, expected at most %d", e.Version, ExportVersion)
+}
+
+type UnrecognizedImportError struct {
+	Header string
+}
+
+func (e *UnrecognizedImportError) Error() string {
+	return fmt.Sprintf("Unrecognized import format with header %q", e.Header)
+}
+
+// missingName is the reason rows without a habit name are skipped
+const missingName = "missing habit name"
+
+// errDryRun rolls back the import transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// Import reads habits and completions from r and adds them in a single
+// transaction. Missing habits are created, completions are inserted in date
+// order and the streaks of every touched habit are recomputed. An empty
+// format detects it from the content. With dryRun nothing is written but the
+// report still lists what would have happened.
+func (d *Database) Import(r io.Reader, format string, dryRun bool) (ImportReport, error) {
+	report := ImportReport{
+		DryRun:     dryRun,
+		NewHabits:  []string{},
+		Duplicates: []ImportRecord{},
+		Conflicts:  []ImportConflict{},
+	}
+
+	export, err := parseImport(r, format, &report)
+	if err != nil {
+		return report, err
+	}
+
+	err = d.DB.Transaction(func(tx *gorm.DB) error {
+		if err := applyImport(tx, export, d.Today(), &report); err != nil {
+			return err
+		}
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return report, err
+	}
+	return report, nil
+}
+
+// parseImport reads the habits and completions of r and reports the rows it
+// has to skip as conflicts
+func parseImport(r io.Reader, format string, report *ImportReport) (Export, error) {
+	br := bufio.NewReader(r)
+	if format == "" {
+		var err error
+		format, err = detectFormat(br)
+		if err != nil {
+			return Export{}, err
+		}
+	}
+
+	switch format {
+	case FormatJSON:
+		var export Export
+		if err := json.NewDecoder(br).Decode(&export); err != nil {
+			return export, err
+		}
+		if export.Version > ExportVersion {
+			return export, &UnsupportedExportVersionError{Version: export.Version}
+		}
+		named := export.Habits[:0]
+		for i, h := range export.Habits {
+			h.Name = strings.TrimSpace(h.Name)
+			if h.Name == "" {
+				report.Conflicts = append(report.Conflicts, ImportConflict{Row: i + 1, Reason: missingName})
+				continue
+			}
+			named = append(named, h)
+		}
+		export.Habits = named
+		return export, nil
+	case FormatCSV, FormatNameDate:
+		return parseRowsCSV(br, report)
+	case FormatLoop:
+		return parseLoopCSV(br)
+	}
+	return Export{}, &UnknownFormatError{Format: format}
+}
+
+func detectFormat(br *bufio.Reader) (string, error) {
+	// skip a byte order mark
+	if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+		br.Discard(3)
+	}
+
+	peek, err := br.Peek(512)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	trimmed := bytes.TrimSpace(peek)
+	if bytes.HasPrefix(trimmed, []byte("{")) {
+		return FormatJSON, nil
+	}
+
+	header := strings.ToLower(strings.TrimSpace(strings.SplitN(string(trimmed), "\n", 2)[0]))
+	switch {
+	case strings.HasPrefix(header, "habit,date"):
+		return FormatCSV, nil
+	case strings.HasPrefix(header, "name,date"):
+		return FormatNameDate, nil
+	case strings.HasPrefix(header, "date,"):
+		return FormatLoop, nil
+	}
+	return "", &UnrecognizedImportError{Header: header}
+}
+
+// parseRowsCSV reads a CSV with the habit name in the first column and the
+// date in the second, like the project's own CSV export
+func parseRowsCSV(r io.Reader, report *ImportReport) (Export, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return Export{}, err
+	}
+
+	export := Export{Version: ExportVersion}
+	habits := map[string]int{}
+	// column of the notes, if there is one
+	noteColumn := -1
+	for i, row := range rows {
+		// the first row is the header
+		if i == 0 {
+			for column, name := range row {
+				if strings.EqualFold(strings.TrimSpace(name), "note") {
+					noteColumn = column
+				}
+			}
+			continue
+		}
+		if len(row) < 2 {
+			continue
+		}
+		name := strings.TrimSpace(row[0])
+		if name == "" {
+			report.Conflicts = append(report.Conflicts, ImportConflict{
+				ImportRecord: ImportRecord{Date: strings.TrimSpace(row[1])},
+				Row:          i + 1,
+				Reason:       missingName,
+			})
+			continue
+		}
+		index, ok := habits[name]
+		if !ok {
+			index = len(export.Habits)
+			habits[name] = index
+			export.Habits = append(export.Habits, ExportedHabit{Name: name, Active: true})
+		}
+		completion := ExportedCompletion{Date: strings.TrimSpace(row[1])}
+		if noteColumn >= 0 && noteColumn < len(row) {
+			completion.Note = strings.TrimSpace(row[noteColumn])
+		}
+		export.Habits[index].Completions = append(export.Habits[index].Completions, completion)
+	}
+	return export, nil
+}
+
+func parseLoopCSV(r io.Reader) (Export, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil || len(rows) == 0 {
+		return Export{}, err
+	}
+
+	export := Export{Version: ExportVersion}
+	// column index of each habit in the rows
+	var columns []int
+	for i, name := range rows[0][1:] {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		columns = append(columns, i+1)
+		export.Habits = append(export.Habits, ExportedHabit{Name: strings.TrimSpace(name), Active: true})
+	}
+
+	for _, row := range rows[1:] {
+		for h, column := range columns {
+			if column < len(row) && strings.TrimSpace(row[column]) == loopChecked {
+				export.Habits[h].Completions = append(export.Habits[h].Completions,
+					ExportedCompletion{Date: strings.TrimSpace(row[0])})
+			}
+		}
+	}
+	return export, nil
+}
+
+func applyImport(tx *gorm.DB, export Export, today Date, report *ImportReport) error {
+	for _, imported := range export.Habits {
+		var h Habit
+		err := tx.Where("name = ?", imported.Name).First(&h).Error
+		isNew := errors.Is(err, gorm.ErrRecordNotFound)
+		if err != nil && !isNew {
+			return err
+		}
+
+		completions := validCompletions(imported, today, report)
+		if len(completions) == 0 && !isNew {
+			continue
+		}
+
+		if isNew {
+			h = newImportedHabit(imported, completions, today)
+			if err := tx.Create(&h).Error; err != nil {
+				return err
+			}
+			if err := setTags(tx, &h, ParseTags(strings.Join(imported.Tags, ","))); err != nil {
+				return err
+			}
+			for _, e := range importedEvents(imported, h, completions) {
+				if err := tx.Create(&e).Error; err != nil {
+					return err
+				}
+			}
+			report.NewHabits = append(report.NewHabits, h.Name)
+		} else if conflict := settingsConflict(h, imported); conflict != "" {
+			for _, c := range completions {
+				report.Conflicts = append(report.Conflicts, ImportConflict{
+					ImportRecord: ImportRecord{Habit: h.Name, Date: c.Date, Amount: c.Amount},
+					Reason:       conflict,
+				})
+			}
+			continue
+		}
+
+		var existing []Completion
+		if err := tx.Where("habit_id = ?", h.ID).Find(&existing).Error; err != nil {
+			return err
+		}
+		seen := map[Date]bool{}
+		for _, c := range existing {
+			seen[c.RecordedAt] = true
+		}
+
+		var inserted Date
+		for _, c := range completions {
+			record := ImportRecord{Habit: h.Name, Date: c.Date, Amount: c.Amount}
+			if seen[c.day] {
+				report.Duplicates = append(report.Duplicates, record)
+				continue
+			}
+			seen[c.day] = true
+
+			amount := c.Amount
+			if amount == 0 {
+				// rows without an amount mean the target was reached
+				amount = h.Target
+			}
+			completion := Completion{RecordedAt: c.day, HabitID: h.ID, Amount: amount, Note: c.Note}
+			if err := tx.Create(&completion).Error; err != nil {
+				return err
+			}
+			report.Imported++
+			if inserted.IsZero() {
+				inserted = c.day
+			}
+		}
+
+		if !inserted.IsZero() {
+			if err := recomputeStreaksFrom(tx, h.ID, inserted); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// validCompletion is an imported completion whose date has been checked
+type validCompletion struct {
+	ExportedCompletion
+	day Date
+}
+
+// validCompletions returns the completions of imported that have a valid
+// date, in date order, and reports the others as conflicts
+func validCompletions(imported ExportedHabit, today Date, report *ImportReport) []validCompletion {
+	var valid []validCompletion
+	for _, c := range imported.Completions {
+		record := ImportRecord{Habit: imported.Name, Date: c.Date, Amount: c.Amount}
+
+		day, err := ParseDate(c.Date)
+		switch {
+		case err != nil:
+			report.Conflicts = append(report.Conflicts, ImportConflict{ImportRecord: record, Reason: "invalid date"})
+		case day.After(today):
+			report.Conflicts = append(report.Conflicts, ImportConflict{ImportRecord: record, Reason: "date in the future"})
+		default:
+			valid = append(valid, validCompletion{ExportedCompletion: c, day: day})
+		}
+	}
+
+	sort.SliceStable(valid, func(i, j int) bool {
+		return valid[i].day.Before(valid[j].day)
+	})
+	return valid
+}
+
+func newImportedHabit(imported ExportedHabit, completions []validCompletion, today Date) Habit {
+	h := Habit{
+		Name:        imported.Name,
+		Description: imported.Description,
+		Active:      imported.Active,
+		Schedule: Schedule{
+			Kind:     imported.Schedule.Kind,
+			Weekdays: imported.Schedule.Weekdays,
+			Count:    imported.Schedule.Count,
+		},
+		Target: imported.Target,
+		Unit:   imported.Unit,
+	}
+	if h.Schedule.Kind == "" {
+		h.Schedule = DailySchedule()
+	}
+	// a reminder that can't be read is left out rather than failing the import
+	h.RemindAt, _ = ParseReminder(imported.RemindAt)
+	// without a creation date the habit is as old as its history
+	createdOn, err := ParseDate(imported.CreatedAt)
+	switch {
+	case err == nil:
+		h.CreatedOn = createdOn
+	case len(completions) > 0:
+		h.CreatedOn = completions[0].day
+	default:
+		h.CreatedOn = today
+	}
+	return h
+}
+
+// importedEvents returns the days the new habit h was archived and restored
+// according to imported, skipping events that can't be read. An archived
+// habit without a matching event is taken to be archived since its last
+// completion, or since it was created if it has none, so that the days after
+// don't count as missed.
+func importedEvents(imported ExportedHabit, h Habit, completions []validCompletion) []HabitEvent {
+	var events []HabitEvent
+	archived := false
+	for _, e := range imported.Events {
+		day, err := ParseDate(e.Date)
+		if err != nil || (e.Kind != EventArchived && e.Kind != EventRestored) {
+			continue
+		}
+		if len(events) > 0 && day.Before(events[len(events)-1].Day) {
+			continue
+		}
+		events = append(events, HabitEvent{HabitID: h.ID, Kind: e.Kind, Day: day, KeepStreak: e.KeepStreak})
+		archived = e.Kind == EventArchived
+	}
+
+	if !h.Active && !archived {
+		day := h.CreatedOn
+		if len(completions) > 0 {
+			day = completions[len(completions)-1].day
+		}
+		events = append(events, HabitEvent{HabitID: h.ID, Kind: EventArchived, Day: day})
+	}
+	return events
+}
+
+// settingsConflict explains why the completions of imported cannot be added
+// to the existing habit h. Imports that don't carry settings never conflict.
+func settingsConflict(h Habit, imported ExportedHabit) string {
+	if imported.Schedule.Kind == "" {
+		return ""
+	}
+	if imported.Target != h.Target || imported.Unit != h.Unit {
+		return fmt.Sprintf("target %s differs from existing %s",
+			FormatAmount(imported.Target, imported.Unit), FormatAmount(h.Target, h.Unit))
+	}
+	return ""
+}
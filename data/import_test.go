@@ -0,0 +1,252 @@
+package data
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestImport(t *testing.T) {
+	daysAgo := func(n int) string {
+		return testNow.AddDate(0, 0, -n).Format("2006-01-02")
+	}
+
+	t.Run("imports a name,date csv and recomputes streaks", func(t *testing.T) {
+		db := setup(t)
+		g := Database{DB: db, Clock: testClock}
+
+		in := "name,date\n" +
+			"swim," + daysAgo(1) + "\n" +
+			"swim," + daysAgo(3) + "\n" +
+			"swim," + daysAgo(2) + "\n" +
+			"read," + daysAgo(1) + "\n" +
+			"read," + daysAgo(2) + "\n"
+
+		report, err := g.Import(strings.NewReader(in), "", false)
+		didNotExpectError(t, err)
+
+		if len(report.NewHabits) != 1 || report.NewHabits[0] != "swim" {
+			t.Errorf("got new habits %v want [swim]", report.NewHabits)
+		}
+		if report.Imported != 4 {
+			t.Errorf("got %d imported want %d", report.Imported, 4)
+		}
+		if len(report.Duplicates) != 1 {
+			t.Errorf("got %d duplicates want %d", len(report.Duplicates), 1)
+		}
+
+		swim, err := g.GetHabit("swim")
+		didNotExpectError(t, err)
+		if swim.CreatedOn.String() != daysAgo(3) {
+			t.Errorf("got created on %s want %s", swim.CreatedOn, daysAgo(3))
+		}
+
+		result, err := g.getCompletionAtTime("swim", yesterday())
+		didNotExpectError(t, err)
+		if result.Streak != 3 {
+			t.Errorf("got streak %d want %d", result.Streak, 3)
+		}
+
+		// read continues from the seeded completion two days ago
+		result, err = g.getCompletionAtTime("read", yesterday())
+		didNotExpectError(t, err)
+		if result.Streak != 5 {
+			t.Errorf("got streak %d want %d", result.Streak, 5)
+		}
+	})
+
+	t.Run("dry run reports without writing", func(t *testing.T) {
+		db := setup(t)
+		g := Database{DB: db, Clock: testClock}
+
+		in := "name,date\nswim," + daysAgo(1) + "\nswim,yesterday\nswim,2999-01-01\n"
+		report, err := g.Import(strings.NewReader(in), FormatNameDate, true)
+		didNotExpectError(t, err)
+
+		if !report.DryRun || report.Imported != 1 || len(report.Conflicts) != 2 {
+			t.Errorf("got %+v", report)
+		}
+
+		_, err = g.GetHabit("swim")
+		assertRecordNotFound(t, err)
+	})
+
+	t.Run("reports rows without a habit name", func(t *testing.T) {
+		db := setup(t)
+		g := Database{DB: db, Clock: testClock}
+
+		in := "name,date\nswim," + daysAgo(1) + "\n,2024-03-01\n"
+		report, err := g.Import(strings.NewReader(in), "", false)
+		didNotExpectError(t, err)
+
+		if report.Imported != 1 || len(report.Conflicts) != 1 {
+			t.Fatalf("got %+v", report)
+		}
+		want := ImportConflict{ImportRecord: ImportRecord{Date: "2024-03-01"}, Row: 3, Reason: "missing habit name"}
+		if report.Conflicts[0] != want {
+			t.Errorf("got conflict %+v want %+v", report.Conflicts[0], want)
+		}
+		_, err = g.GetHabit("")
+		assertRecordNotFound(t, err)
+
+		in = `{"version": 1, "habits": [{"name": " ", "active": true, "completions": [{"date": "` + daysAgo(1) + `"}]}]}`
+		report, err = g.Import(strings.NewReader(in), FormatJSON, false)
+		didNotExpectError(t, err)
+		if report.Imported != 0 || len(report.Conflicts) != 1 || report.Conflicts[0].Row != 1 {
+			t.Errorf("got %+v", report)
+		}
+		_, err = g.GetHabit(" ")
+		assertRecordNotFound(t, err)
+	})
+
+	t.Run("round trips its own json export", func(t *testing.T) {
+		db := setup(t)
+		g := Database{DB: db, Clock: testClock}
+
+		_, err := g.CreateQuantitativeHabit("drink water", DailySchedule(), 8, "glasses")
+		didNotExpectError(t, err)
+		_, err = g.RecordAmount("drink water", 3)
+		didNotExpectError(t, err)
+		_, err = g.SetCompletionNote("drink water", testNow, "too much coffee")
+		didNotExpectError(t, err)
+		_, err = g.EditHabit("drink water", HabitEdit{
+			Name: "drink water", Description: "from the tap", Schedule: DailySchedule(), Tags: []string{"health"},
+			RemindAt: "10:00",
+		})
+		didNotExpectError(t, err)
+
+		var buf bytes.Buffer
+		didNotExpectError(t, g.WriteExport(&buf, FormatJSON))
+
+		db.Exec("DELETE FROM completions")
+		db.Exec("DELETE FROM habits WHERE name = ?", "drink water")
+
+		report, err := g.Import(&buf, "", false)
+		didNotExpectError(t, err)
+		if report.Imported != 9 {
+			t.Errorf("got %d imported want %d", report.Imported, 9)
+		}
+
+		water, err := g.GetHabit("drink water")
+		didNotExpectError(t, err)
+		if water.Target != 8 || water.Unit != "glasses" {
+			t.Errorf("got target %v %s", water.Target, water.Unit)
+		}
+		if water.Description != "from the tap" || len(water.Tags) != 1 {
+			t.Errorf("got description %q and tags %v want %q and [health]", water.Description, water.TagNames(), "from the tap")
+		}
+		if water.RemindAt != "10:00" {
+			t.Errorf("got reminder %q want %q", water.RemindAt, "10:00")
+		}
+		history, err := g.GetHistory("drink water")
+		didNotExpectError(t, err)
+		if len(history) != 1 || history[0].Note != "too much coffee" {
+			t.Errorf("got %+v want the completion with its note", history)
+		}
+
+		clean, err := g.GetHabit("clean")
+		didNotExpectError(t, err)
+		if clean.Active {
+			t.Errorf("expected archived habit to stay archived")
+		}
+	})
+
+	t.Run("round trips when habits were archived and restored", func(t *testing.T) {
+		db := setup(t)
+		g := Database{DB: db, Clock: testClock}
+
+		didNotExpectError(t, g.ArchiveHabitOn("cook", testNow.AddDate(0, 0, -5)))
+		didNotExpectError(t, g.RestoreHabitOn("cook", testNow.AddDate(0, 0, -3), true))
+		didNotExpectError(t, g.ArchiveHabitOn("cook", testNow))
+
+		var buf bytes.Buffer
+		didNotExpectError(t, g.WriteExport(&buf, FormatJSON))
+
+		db.Exec("DELETE FROM habit_events")
+		db.Exec("DELETE FROM completions")
+		db.Exec("DELETE FROM habits")
+
+		_, err := g.Import(&buf, "", false)
+		didNotExpectError(t, err)
+
+		events, err := g.GetHabitEvents("cook")
+		didNotExpectError(t, err)
+		want := []HabitEvent{
+			{Kind: EventArchived, Day: today().AddDays(-5)},
+			{Kind: EventRestored, Day: today().AddDays(-3), KeepStreak: true},
+			{Kind: EventArchived, Day: today()},
+		}
+		if len(events) != len(want) {
+			t.Fatalf("got events %+v want %+v", events, want)
+		}
+		for i, e := range events {
+			if e.Kind != want[i].Kind || !e.Day.Equal(want[i].Day) || e.KeepStreak != want[i].KeepStreak {
+				t.Errorf("got event %+v want %+v", e, want[i])
+			}
+		}
+
+		// clean was archived without an event, so it gets one on the day it
+		// was created as it has no completions
+		clean, err := g.GetHabitEvents("clean")
+		didNotExpectError(t, err)
+		if len(clean) != 1 || clean[0].Kind != EventArchived || !clean[0].Day.Equal(today()) {
+			t.Errorf("got events %+v want clean archived today", clean)
+		}
+	})
+
+	t.Run("archives imported habits since their last completion", func(t *testing.T) {
+		db := setup(t)
+		g := Database{DB: db, Clock: testClock}
+
+		in := `{"version": 1, "habits": [{"name": "swim", "active": false, "schedule": {"kind": "daily"},
+			"completions": [{"date": "` + daysAgo(9) + `"}, {"date": "` + daysAgo(8) + `"}]}]}`
+		_, err := g.Import(strings.NewReader(in), "", false)
+		didNotExpectError(t, err)
+
+		between := habitNames(g.GetHabitsActiveBetween(today().AddDays(-5), today(), true, ""))
+		for _, name := range between {
+			if name == "swim" {
+				t.Errorf("got swim tracked after it was archived")
+			}
+		}
+
+		didNotExpectError(t, g.RestoreHabit("swim"))
+		events, err := g.GetHabitEvents("swim")
+		didNotExpectError(t, err)
+		if len(events) != 2 || !events[0].Day.Equal(today().AddDays(-8)) || events[1].Kind != EventRestored {
+			t.Errorf("got events %+v want archived 8 days ago and restored", events)
+		}
+	})
+
+	t.Run("imports loop habit tracker checkmarks", func(t *testing.T) {
+		db := setup(t)
+		g := Database{DB: db, Clock: testClock}
+
+		in := "Date,Meditate,Stretch,\n" +
+			daysAgo(1) + ",2,0,\n" +
+			daysAgo(2) + ",2,-1,\n" +
+			daysAgo(3) + ",1,2,\n"
+
+		report, err := g.Import(strings.NewReader(in), "", false)
+		didNotExpectError(t, err)
+		if len(report.NewHabits) != 2 || report.Imported != 3 {
+			t.Errorf("got %+v", report)
+		}
+
+		streak, err := g.CurrentStreak("Meditate")
+		didNotExpectError(t, err)
+		if streak != 2 {
+			t.Errorf("got streak %d want %d", streak, 2)
+		}
+	})
+
+	t.Run("rejects unknown content", func(t *testing.T) {
+		db := setup(t)
+		g := Database{DB: db, Clock: testClock}
+
+		_, err := g.Import(strings.NewReader("foo,bar\n1,2\n"), "", false)
+		if _, ok := err.(*UnrecognizedImportError); !ok {
+			t.Errorf("expected unrecognized import error, got %v", err)
+		}
+	})
+}
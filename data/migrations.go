@@ -0,0 +1,376 @@
+package data
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SchemaMigration records a migration that has been applied to the database
+type SchemaMigration struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (SchemaMigration) TableName() string {
+	return "schema_version"
+}
+
+type migration struct {
+	version int
+	name    string
+	up      func(tx *gorm.DB) error
+}
+
+// migrations are applied in order of their version. Version 0 is the
+// unversioned schema that AutoMigrate created before migrations existed.
+// Every change to Habit or Completion, even adding a column, needs a
+// migration appended here, as AutoMigrate is no longer run on existing
+// databases.
+var migrations = []migration{
+	{version: 1, name: "store dates in date columns", up: migrateDates},
+	{version: 2, name: "record when habits were archived", up: migrateArchivedOn},
+	{version: 3, name: "keep a history of archiving and restoring", up: migrateHabitEvents},
+	{version: 4, name: "add descriptions and colours to habits", up: migrateDescriptionAndColor},
+	{version: 5, name: "add notes to completions", up: migrateCompletionNotes},
+	{version: 6, name: "tag habits", up: migrateTags},
+	{version: 7, name: "add reminder times to habits", up: migrateReminders},
+}
+
+// MigrationState tells whether a migration has been applied to a database
+type MigrationState struct {
+	Version   int
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrate brings the database schema up to date. A new database gets the
+// current schema right away. An existing one is first backed up next to the
+// database file and then has every pending migration applied in order, each
+// in its own transaction. It returns the path of the backup, which is empty
+// if nothing had to be migrated or the database is not a file.
+func Migrate(db *gorm.DB) (string, error) {
+	fresh := !db.Migrator().HasTable("habits")
+
+	if err := db.AutoMigrate(&SchemaMigration{}); err != nil {
+		return "", err
+	}
+
+	if fresh {
+		return "", db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Migrator().CreateTable(&Habit{}, &Completion{}, &HabitEvent{}, &Tag{}, &habitTag{}); err != nil {
+				return err
+			}
+			for _, m := range migrations {
+				if err := recordMigration(tx, m); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	current, err := schemaVersion(db)
+	if err != nil {
+		return "", err
+	}
+	if current >= migrations[len(migrations)-1].version {
+		return "", nil
+	}
+
+	backup, err := backupDatabase(db, current)
+	if err != nil {
+		return "", err
+	}
+
+	if current == 0 {
+		// databases from before versioning may lack columns added since
+		if err := db.AutoMigrate(&habitV0{}, &completionV0{}); err != nil {
+			return backup, err
+		}
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := m.up(tx); err != nil {
+				return err
+			}
+			return recordMigration(tx, m)
+		})
+		if err != nil {
+			return backup, fmt.Errorf("migration %d (%s) failed, backup at %s: %w", m.version, m.name, backup, err)
+		}
+	}
+	return backup, nil
+}
+
+// MigrationStatus lists every migration and whether it has been applied,
+// without changing the database
+func MigrationStatus(db *gorm.DB) ([]MigrationState, error) {
+	applied := map[int]SchemaMigration{}
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		var rows []SchemaMigration
+		if err := db.Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, r := range rows {
+			applied[r.Version] = r
+		}
+	}
+
+	states := make([]MigrationState, len(migrations))
+	for i, m := range migrations {
+		r, ok := applied[m.version]
+		states[i] = MigrationState{
+			Version:   m.version,
+			Name:      m.name,
+			Applied:   ok,
+			AppliedAt: r.AppliedAt,
+		}
+	}
+	return states, nil
+}
+
+func schemaVersion(db *gorm.DB) (int, error) {
+	var current int
+	err := db.Model(&SchemaMigration{}).Select("COALESCE(MAX(version), 0)").Scan(&current).Error
+	return current, err
+}
+
+// backupDatabase copies the database next to its file, named after the
+// schema version it is at. In-memory databases are not backed up.
+func backupDatabase(db *gorm.DB, version int) (string, error) {
+	var databases []struct {
+		Seq  int
+		Name string
+		File string
+	}
+	if err := db.Raw("PRAGMA database_list").Scan(&databases).Error; err != nil {
+		return "", err
+	}
+
+	for _, d := range databases {
+		if d.Name != "main" || d.File == "" {
+			continue
+		}
+		path := fmt.Sprintf("%s.v%d-%s.bak", d.File, version, time.Now().Format("20060102-150405"))
+		if err := db.Exec("VACUUM INTO ?", path).Error; err != nil {
+			return "", fmt.Errorf("unable to back up database before migrating: %w", err)
+		}
+		return path, nil
+	}
+	return "", nil
+}
+
+func recordMigration(tx *gorm.DB, m migration) error {
+	return tx.Create(&SchemaMigration{Version: m.version, Name: m.name, AppliedAt: time.Now()}).Error
+}
+
+// habitV0 and completionV0 are the last unversioned schema, which stored
+// dates as strings. Habit.CreatedAt shadowed gorm.Model.CreatedAt and held
+// the day the habit was created.
+type habitV0 struct {
+	gorm.Model
+	Name             string `gorm:"unique;not null"`
+	CreatedAt        string
+	Active           bool
+	ScheduleKind     string `gorm:"default:daily"`
+	ScheduleWeekdays int
+	ScheduleCount    int
+	Target           float64
+	Unit             string
+	Records          []completionV0 `gorm:"foreignKey:HabitID"`
+}
+
+func (habitV0) TableName() string {
+	return "habits"
+}
+
+type completionV0 struct {
+	gorm.Model
+	RecordedAt string
+	Streak     int
+	HabitID    uint
+	Amount     float64
+}
+
+func (completionV0) TableName() string {
+	return "completions"
+}
+
+// habitV1 and completionV1 store dates in date columns and keep the day a
+// habit was created apart from gorm's creation timestamp
+type habitV1 struct {
+	gorm.Model
+	Name             string `gorm:"unique;not null"`
+	CreatedOn        Date
+	Active           bool
+	ScheduleKind     string `gorm:"default:daily"`
+	ScheduleWeekdays int
+	ScheduleCount    int
+	Target           float64
+	Unit             string
+	Records          []completionV1 `gorm:"foreignKey:HabitID"`
+}
+
+func (habitV1) TableName() string {
+	return "habits"
+}
+
+type completionV1 struct {
+	gorm.Model
+	RecordedAt Date `gorm:"index"`
+	Streak     int
+	HabitID    uint
+	Amount     float64
+}
+
+func (completionV1) TableName() string {
+	return "completions"
+}
+
+// migrateDates rebuilds both tables since SQLite cannot change the type of a
+// column in place
+func migrateDates(tx *gorm.DB) error {
+	statements := []string{
+		// the indexes move along with the renamed tables but keep their names
+		"DROP INDEX IF EXISTS idx_habits_deleted_at",
+		"DROP INDEX IF EXISTS idx_completions_deleted_at",
+		"ALTER TABLE habits RENAME TO habits_v0",
+		"ALTER TABLE completions RENAME TO completions_v0",
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Migrator().CreateTable(&habitV1{}, &completionV1{}); err != nil {
+		return err
+	}
+
+	statements = []string{
+		`INSERT INTO habits (id, created_at, updated_at, deleted_at, name, created_on, active,
+			schedule_kind, schedule_weekdays, schedule_count, target, unit)
+		SELECT id, COALESCE(NULLIF(created_at, ''), updated_at), updated_at, deleted_at, name,
+			COALESCE(DATE(NULLIF(created_at, '')), DATE(updated_at)), active,
+			schedule_kind, schedule_weekdays, schedule_count, target, unit
+		FROM habits_v0`,
+		// rows without a readable date could not be loaded anymore
+		`INSERT INTO completions (id, created_at, updated_at, deleted_at, recorded_at, streak, habit_id, amount)
+		SELECT id, created_at, updated_at, deleted_at, DATE(recorded_at), streak, habit_id, amount
+		FROM completions_v0
+		WHERE DATE(recorded_at) IS NOT NULL`,
+		"DROP TABLE completions_v0",
+		"DROP TABLE habits_v0",
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateArchivedOn takes the last update of habits that are already
+// archived as the day they were archived
+func migrateArchivedOn(tx *gorm.DB) error {
+	statements := []string{
+		"ALTER TABLE habits ADD COLUMN archived_on date",
+		"UPDATE habits SET archived_on = DATE(updated_at) WHERE active = false",
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// habitEventV3 is HabitEvent as of version 3. Its foreign key is named like
+// the one gorm derives from Habit.Events for new databases.
+type habitEventV3 struct {
+	ID         uint
+	HabitID    uint    `gorm:"index"`
+	Habit      habitV1 `gorm:"foreignKey:HabitID;constraint:fk_habits_events,"`
+	Kind       string
+	Day        Date
+	KeepStreak bool
+	CreatedAt  time.Time
+}
+
+func (habitEventV3) TableName() string {
+	return "habit_events"
+}
+
+// migrateHabitEvents turns the day habits were archived into the first
+// event of their history
+func migrateHabitEvents(tx *gorm.DB) error {
+	if err := tx.Migrator().CreateTable(&habitEventV3{}); err != nil {
+		return err
+	}
+
+	statements := []string{
+		`INSERT INTO habit_events (habit_id, kind, day, keep_streak, created_at)
+		SELECT id, 'archived', archived_on, false, updated_at
+		FROM habits
+		WHERE active = false AND archived_on IS NOT NULL`,
+		"ALTER TABLE habits DROP COLUMN archived_on",
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateDescriptionAndColor(tx *gorm.DB) error {
+	statements := []string{
+		"ALTER TABLE habits ADD COLUMN description text",
+		"ALTER TABLE habits ADD COLUMN color text",
+	}
+	for _, s := range statements {
+		if err := tx.Exec(s).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func migrateCompletionNotes(tx *gorm.DB) error {
+	return tx.Exec("ALTER TABLE completions ADD COLUMN note text").Error
+}
+
+// tagV6 and habitTagV6 are Tag and its join table as of version 6
+type tagV6 struct {
+	ID   uint
+	Name string `gorm:"unique;not null"`
+}
+
+func (tagV6) TableName() string {
+	return "tags"
+}
+
+type habitTagV6 struct {
+	HabitID uint `gorm:"primaryKey"`
+	TagID   uint `gorm:"primaryKey"`
+}
+
+func (habitTagV6) TableName() string {
+	return "habit_tags"
+}
+
+func migrateTags(tx *gorm.DB) error {
+	return tx.Migrator().CreateTable(&tagV6{}, &habitTagV6{})
+}
+
+func migrateReminders(tx *gorm.DB) error {
+	return tx.Exec("ALTER TABLE habits ADD COLUMN remind_at text").Error
+}
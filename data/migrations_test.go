@@ -0,0 +1,188 @@
+package data
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestFile(t *testing.T) *gorm.DB {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "habits.db")
+	db, err := gorm.Open(sqlite.Open(path),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("unable to open SQLite DB: %v", err)
+	}
+	return db
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("creates the current schema for a new database", func(t *testing.T) {
+		db := openTestFile(t)
+		backup, err := Migrate(db)
+		didNotExpectError(t, err)
+		if backup != "" {
+			t.Errorf("expected no backup of a new database, got %s", backup)
+		}
+
+		var applied []SchemaMigration
+		db.Order("version").Find(&applied)
+		if len(applied) != len(migrations) {
+			t.Errorf("got %d applied migrations want %d", len(applied), len(migrations))
+		}
+
+		g := Database{DB: db, Clock: testClock}
+		_, err = g.CreateHabit("cook")
+		didNotExpectError(t, err)
+	})
+
+	t.Run("converts string dates of an unversioned database", func(t *testing.T) {
+		db := openTestFile(t)
+		// the schema as AutoMigrate created it before dates were dates
+		didNotExpectError(t, db.AutoMigrate(&habitV0{}, &completionV0{}))
+		db.Create(&habitV0{Name: "cook", CreatedAt: "2022-12-30", Active: true, ScheduleKind: "daily"})
+		db.Create(&completionV0{RecordedAt: "2022-12-31", Streak: 1, HabitID: 1})
+		db.Create(&completionV0{RecordedAt: "2023-01-01", Streak: 2, HabitID: 1})
+		db.Create(&habitV0{Name: "read", CreatedAt: "2022-12-30", Active: false, ScheduleKind: "daily"})
+
+		backup, err := Migrate(db)
+		didNotExpectError(t, err)
+
+		// the backup still holds the unmigrated data
+		old, err := gorm.Open(sqlite.Open(backup),
+			&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		didNotExpectError(t, err)
+		var recordedAt string
+		old.Raw("SELECT recorded_at FROM completions ORDER BY id LIMIT 1").Scan(&recordedAt)
+		if recordedAt != "2022-12-31" {
+			t.Errorf("got %q in the backup want %q", recordedAt, "2022-12-31")
+		}
+
+		g := Database{DB: db, Clock: testClock}
+		h, err := g.GetHabit("cook")
+		didNotExpectError(t, err)
+		if h.CreatedOn != NewDate(2022, time.December, 30) {
+			t.Errorf("got created on %v want 2022-12-30", h.CreatedOn)
+		}
+		if h.CreatedAt.IsZero() {
+			t.Errorf("expected created at timestamp to be kept")
+		}
+
+		events, err := g.GetHabitEvents("read")
+		didNotExpectError(t, err)
+		if len(events) != 1 || events[0].Kind != EventArchived || events[0].Day.IsZero() {
+			t.Errorf("got %+v want the day read was archived", events)
+		}
+
+		// range queries compare real dates now
+		inJanuary := g.GetActiveHabitsAndCompletions(1, 2023)
+		if len(inJanuary) != 1 || inJanuary[0].Completion.RecordedAt != NewDate(2023, time.January, 1) {
+			t.Errorf("got %+v want only the completion of 2023-01-01", inJanuary)
+		}
+
+		years := g.GetAvailableYears()
+		if len(years) != 2 {
+			t.Errorf("got years %v want 2022 and 2023", years)
+		}
+
+		// running it again doesn't do anything
+		backup, err = Migrate(db)
+		didNotExpectError(t, err)
+		if backup != "" {
+			t.Errorf("expected no backup without pending migrations, got %s", backup)
+		}
+		var count int64
+		db.Model(&Completion{}).Count(&count)
+		if count != 2 {
+			t.Errorf("got %d completions want %d", count, 2)
+		}
+	})
+}
+
+func TestMigratedSchema(t *testing.T) {
+	fresh := openTestFile(t)
+	_, err := Migrate(fresh)
+	didNotExpectError(t, err)
+
+	upgraded := openTestFile(t)
+	didNotExpectError(t, upgraded.AutoMigrate(&habitV0{}, &completionV0{}))
+	_, err = Migrate(upgraded)
+	didNotExpectError(t, err)
+
+	got, want := schemaOf(t, upgraded), schemaOf(t, fresh)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got upgraded schema\n%v\nwant the schema of a new database\n%v", got, want)
+	}
+}
+
+// schemaOf describes the columns and foreign keys of every table, leaving
+// out the order of the columns, which ALTER TABLE doesn't keep
+func schemaOf(t *testing.T, db *gorm.DB) map[string][]string {
+	t.Helper()
+	var tables []string
+	didNotExpectError(t, db.Raw("SELECT name FROM sqlite_master WHERE type = 'table' ORDER BY name").Scan(&tables).Error)
+
+	schema := map[string][]string{}
+	for _, table := range tables {
+		var columns []struct {
+			Name    string
+			Type    string
+			Notnull bool
+			Pk      int
+		}
+		didNotExpectError(t, db.Raw("SELECT name, type, \"notnull\", pk FROM pragma_table_info(?)", table).Scan(&columns).Error)
+		for _, c := range columns {
+			schema[table] = append(schema[table], fmt.Sprintf("column %s %s notnull=%v pk=%d", c.Name, c.Type, c.Notnull, c.Pk))
+		}
+
+		var keys []struct {
+			Table string
+			From  string
+			To    string
+		}
+		didNotExpectError(t, db.Raw("SELECT \"table\", \"from\", \"to\" FROM pragma_foreign_key_list(?)", table).Scan(&keys).Error)
+		for _, k := range keys {
+			schema[table] = append(schema[table], fmt.Sprintf("foreign key %s references %s(%s)", k.From, k.Table, k.To))
+		}
+		sort.Strings(schema[table])
+	}
+	return schema
+}
+
+func TestMigrationStatus(t *testing.T) {
+	db := openTestFile(t)
+	didNotExpectError(t, db.AutoMigrate(&habitV0{}, &completionV0{}))
+
+	states, err := MigrationStatus(db)
+	didNotExpectError(t, err)
+	for _, s := range states {
+		if s.Applied {
+			t.Errorf("expected migration %d to be pending", s.Version)
+		}
+	}
+	if db.Migrator().HasTable(&SchemaMigration{}) {
+		t.Error("expected status to leave the database untouched")
+	}
+
+	_, err = Migrate(db)
+	didNotExpectError(t, err)
+
+	states, err = MigrationStatus(db)
+	didNotExpectError(t, err)
+	if len(states) != len(migrations) {
+		t.Fatalf("got %d migrations want %d", len(states), len(migrations))
+	}
+	for _, s := range states {
+		if !s.Applied || s.AppliedAt.IsZero() {
+			t.Errorf("expected migration %d to be applied, got %+v", s.Version, s)
+		}
+	}
+}
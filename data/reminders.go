@@ -0,0 +1,126 @@
+package data
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const reminderLayout = "15:04"
+
+type InvalidReminderError struct {
+	Time string
+}
+
+func (e *InvalidReminderError) Error() string {
+	return fmt.Sprintf("Invalid reminder time %q, expected something like 08:30", e.Time)
+}
+
+// ParseReminder accepts times of day like "8:30" or "20:00" and returns them
+// as HH:MM. An empty string means no reminder.
+func ParseReminder(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	t, err := time.Parse(reminderLayout, s)
+	if err != nil {
+		return "", &InvalidReminderError{Time: s}
+	}
+	return t.Format(reminderLayout), nil
+}
+
+// SetReminder sets the time of day to be reminded of habit, see
+// ParseReminder. An empty time removes the reminder.
+func (d *Database) SetReminder(habit, at string) (Habit, error) {
+	at, err := ParseReminder(at)
+	if err != nil {
+		return Habit{}, err
+	}
+	h, err := d.GetHabit(habit)
+	if err != nil {
+		return h, err
+	}
+	h.RemindAt = at
+	return h, d.DB.Model(&h).Update("remind_at", at).Error
+}
+
+// ReminderTime returns the moment on day a reminder at the time of day at
+// goes off. Times before the day start hour fall on the next calendar day,
+// as they still belong to day.
+func (c Calendar) ReminderTime(day Date, at string) (time.Time, error) {
+	t, err := time.Parse(reminderLayout, at)
+	if err != nil {
+		return time.Time{}, &InvalidReminderError{Time: at}
+	}
+	moment := time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, c.location())
+	if t.Hour() < c.DayStartHour {
+		moment = moment.AddDate(0, 0, 1)
+	}
+	return moment, nil
+}
+
+// DueReminders returns the active habits whose reminder went off after since
+// and no later than now, and which are due but not done yet. Only the
+// reminders of the day it is now are considered.
+func (d *Database) DueReminders(since, now time.Time) ([]Habit, error) {
+	var habits []Habit
+	err := d.DB.Preload("Records", func(db *gorm.DB) *gorm.DB {
+		return db.Order("recorded_at")
+	}).Preload("Events").
+		Where("active = ? AND remind_at IS NOT NULL AND remind_at != ''", true).
+		Order("remind_at, name").
+		Find(&habits).Error
+	if err != nil {
+		return nil, err
+	}
+
+	today := d.Calendar.DateOf(now)
+	var due []Habit
+	for _, h := range habits {
+		at, err := d.Calendar.ReminderTime(today, h.RemindAt)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", h.Name, err)
+		}
+		if at.After(since) && !at.After(now) && h.dueOn(today) {
+			due = append(due, h)
+		}
+	}
+	return due, nil
+}
+
+// dueOn reports whether h still needs doing on day according to its
+// schedule. Habits done that day are not due, nor are weekly and monthly
+// ones whose target for the period is already met or interval ones done
+// within the interval. It needs the habit's Records and Events to be loaded.
+func (h Habit) dueOn(day Date) bool {
+	if !h.TrackedOn(day) {
+		return false
+	}
+
+	var met []time.Time
+	for _, c := range h.Records {
+		if h.met(c) && !c.RecordedAt.After(day) {
+			met = append(met, c.RecordedAt.Time)
+		}
+	}
+	if len(met) > 0 && met[len(met)-1].Equal(day.Time) {
+		return false
+	}
+
+	switch h.Schedule.Kind {
+	case ScheduleWeekdays:
+		return h.Schedule.Weekdays&(1<<day.Weekday()) != 0
+	case ScheduleWeekly:
+		start := startOfWeek(day.Time)
+		return countBetween(met, start, start.AddDate(0, 0, 7)) < h.Schedule.Count
+	case ScheduleMonthly:
+		start := startOfMonth(day.Time)
+		return countBetween(met, start, start.AddDate(0, 1, 0)) < h.Schedule.Count
+	case ScheduleInterval:
+		return countBetween(met, day.AddDays(1-h.Schedule.Count).Time, day.AddDays(1).Time) == 0
+	}
+	return true
+}
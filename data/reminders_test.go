@@ -0,0 +1,156 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseReminder(t *testing.T) {
+	valid := map[string]string{
+		"8:30":   "08:30",
+		" 20:00": "20:00",
+		"":       "",
+	}
+	for in, want := range valid {
+		got, err := ParseReminder(in)
+		didNotExpectError(t, err)
+		if got != want {
+			t.Errorf("got %q for %q want %q", got, in, want)
+		}
+	}
+
+	for _, in := range []string{"25:00", "noon", "8"} {
+		if _, err := ParseReminder(in); err == nil {
+			t.Errorf("expected an error for %q", in)
+		}
+	}
+}
+
+func TestReminderTime(t *testing.T) {
+	c := Calendar{Location: time.UTC, DayStartHour: 4}
+
+	got, err := c.ReminderTime(NewDate(2024, time.March, 15), "20:30")
+	didNotExpectError(t, err)
+	if want := time.Date(2024, time.March, 15, 20, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	// 1am still belongs to the day before when days start at 4am
+	got, err = c.ReminderTime(NewDate(2024, time.March, 15), "01:00")
+	didNotExpectError(t, err)
+	if want := time.Date(2024, time.March, 16, 1, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestDueReminders(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	for habit, at := range map[string]string{
+		"cook":        "8:00",
+		"read":        "18:00",
+		"clean":       "08:00",
+		"play guitar": "07:00",
+	} {
+		_, err := g.SetReminder(habit, at)
+		didNotExpectError(t, err)
+	}
+	// testNow is a Friday
+	saturdays, _ := ParseSchedule(ScheduleWeekdays, "sat")
+	_, err := g.EditHabit("garden", HabitEdit{Name: "garden", Schedule: saturdays, RemindAt: "09:00"})
+	didNotExpectError(t, err)
+
+	t.Run("returns due habits not done yet", func(t *testing.T) {
+		due, err := g.DueReminders(time.Time{}, testNow)
+		didNotExpectError(t, err)
+		if got := habitNames(due); !reflect.DeepEqual(got, []string{"cook"}) {
+			t.Errorf("got %v want [cook]", got)
+		}
+	})
+
+	t.Run("only returns reminders after since", func(t *testing.T) {
+		due, err := g.DueReminders(testNow.Add(-3*time.Hour), testNow)
+		didNotExpectError(t, err)
+		if len(due) != 0 {
+			t.Errorf("got %v want none", habitNames(due))
+		}
+
+		due, err = g.DueReminders(testNow, testNow.Add(7*time.Hour))
+		didNotExpectError(t, err)
+		if got := habitNames(due); !reflect.DeepEqual(got, []string{"read"}) {
+			t.Errorf("got %v want [read]", got)
+		}
+	})
+
+	t.Run("forgets habits once done", func(t *testing.T) {
+		_, err := g.RecordCompletion("cook")
+		didNotExpectError(t, err)
+
+		due, err := g.DueReminders(time.Time{}, testNow)
+		didNotExpectError(t, err)
+		if len(due) != 0 {
+			t.Errorf("got %v want none", habitNames(due))
+		}
+	})
+
+	t.Run("keeps reminders when renaming", func(t *testing.T) {
+		didNotExpectError(t, g.RenameHabit("read", "read books"))
+		h, err := g.GetHabit("read books")
+		didNotExpectError(t, err)
+		if h.RemindAt != "18:00" {
+			t.Errorf("got reminder %q want %q", h.RemindAt, "18:00")
+		}
+	})
+
+	t.Run("removes reminders", func(t *testing.T) {
+		h, err := g.SetReminder("read books", "")
+		didNotExpectError(t, err)
+		if h.RemindAt != "" {
+			t.Errorf("got reminder %q want none", h.RemindAt)
+		}
+	})
+
+	t.Run("rejects invalid times", func(t *testing.T) {
+		_, err := g.SetReminder("cook", "noon")
+		if _, ok := err.(*InvalidReminderError); !ok {
+			t.Errorf("expected invalid reminder error, got %v", err)
+		}
+	})
+}
+
+func TestDueOn(t *testing.T) {
+	friday := NewDate(2024, time.March, 15)
+	done := func(days ...int) []Completion {
+		var records []Completion
+		for _, d := range days {
+			records = append(records, Completion{RecordedAt: friday.AddDays(-d), Amount: 1})
+		}
+		return records
+	}
+
+	tests := []struct {
+		name     string
+		schedule Schedule
+		target   float64
+		records  []Completion
+		want     bool
+	}{
+		{"daily", DailySchedule(), 0, done(1), true},
+		{"daily done today", DailySchedule(), 0, done(0), false},
+		{"weekly target met", Schedule{Kind: ScheduleWeekly, Count: 1}, 0, done(4), false},
+		{"weekly target met last week", Schedule{Kind: ScheduleWeekly, Count: 1}, 0, done(5), true},
+		{"weekly target not met", Schedule{Kind: ScheduleWeekly, Count: 2}, 0, done(4), true},
+		{"monthly target met", Schedule{Kind: ScheduleMonthly, Count: 2}, 0, done(3, 10), false},
+		{"within interval", Schedule{Kind: ScheduleInterval, Count: 3}, 0, done(2), false},
+		{"interval over", Schedule{Kind: ScheduleInterval, Count: 3}, 0, done(3), true},
+		{"target not reached today", DailySchedule(), 2, done(0), true},
+	}
+	for _, tt := range tests {
+		h := Habit{Name: tt.name, Schedule: tt.schedule, Target: tt.target, Records: tt.records}
+		if got := h.dueOn(friday); got != tt.want {
+			t.Errorf("%s: got %v want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+package data
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ScheduleKind string
+
+const (
+	ScheduleDaily    ScheduleKind = "daily"
+	ScheduleWeekdays ScheduleKind = "weekdays"
+	ScheduleWeekly   ScheduleKind = "weekly"
+	ScheduleMonthly  ScheduleKind = "monthly"
+	ScheduleInterval ScheduleKind = "interval"
+)
+
+var ScheduleKinds = []ScheduleKind{
+	ScheduleDaily,
+	ScheduleWeekdays,
+	ScheduleWeekly,
+	ScheduleMonthly,
+	ScheduleInterval,
+}
+
+// Schedule describes how often a habit is meant to be done.
+// Weekdays is a bitmask indexed by time.Weekday and is only used by
+// ScheduleWeekdays. Count is the number of completions per week or month
+// for ScheduleWeekly and ScheduleMonthly, and the number of days between
+// completions for ScheduleInterval.
+type Schedule struct {
+	Kind     ScheduleKind `gorm:"default:daily"`
+	Weekdays int
+	Count    int
+}
+
+func DailySchedule() Schedule {
+	return Schedule{Kind: ScheduleDaily}
+}
+
+type InvalidScheduleError struct {
+	Reason string
+}
+
+func (e *InvalidScheduleError) Error() string {
+	return "Invalid schedule: " + e.Reason
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseSchedule builds a schedule of the given kind from arg. For
+// ScheduleWeekdays arg is a comma separated list of days such as
+// "mon,wed,fri", for the other non-daily kinds it is a positive number.
+func ParseSchedule(kind ScheduleKind, arg string) (Schedule, error) {
+	arg = strings.TrimSpace(strings.ToLower(arg))
+
+	switch kind {
+	case ScheduleDaily, "":
+		return DailySchedule(), nil
+
+	case ScheduleWeekdays:
+		mask := 0
+		for _, name := range strings.Split(arg, ",") {
+			name = strings.TrimSpace(name)
+			if len(name) > 3 {
+				name = name[:3]
+			}
+			day, ok := weekdayNames[name]
+			if !ok {
+				return Schedule{}, &InvalidScheduleError{Reason: fmt.Sprintf("unknown weekday %q", name)}
+			}
+			mask |= 1 << day
+		}
+		return Schedule{Kind: kind, Weekdays: mask}, nil
+
+	case ScheduleWeekly, ScheduleMonthly, ScheduleInterval:
+		count, err := strconv.Atoi(arg)
+		if err != nil || count < 1 {
+			return Schedule{}, &InvalidScheduleError{Reason: fmt.Sprintf("%q is not a positive number", arg)}
+		}
+		if kind == ScheduleWeekly && count > 7 {
+			return Schedule{}, &InvalidScheduleError{Reason: "a week only has 7 days"}
+		}
+		if kind == ScheduleMonthly && count > 28 {
+			return Schedule{}, &InvalidScheduleError{Reason: "at most 28 times per month"}
+		}
+		return Schedule{Kind: kind, Count: count}, nil
+	}
+
+	return Schedule{}, &InvalidScheduleError{Reason: fmt.Sprintf("unknown kind %q", kind)}
+}
+
+func (s Schedule) String() string {
+	switch s.Kind {
+	case ScheduleWeekdays:
+		var days []string
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if s.Weekdays&(1<<day) != 0 {
+				days = append(days, day.String()[:3])
+			}
+		}
+		return strings.Join(days, ", ")
+	case ScheduleWeekly:
+		return fmt.Sprintf("%d times per week", s.Count)
+	case ScheduleMonthly:
+		return fmt.Sprintf("%d times per month", s.Count)
+	case ScheduleInterval:
+		return fmt.Sprintf("every %d days", s.Count)
+	}
+	return "daily"
+}
+
+// Arg returns the argument ParseSchedule needs to build s again
+func (s Schedule) Arg() string {
+	switch s.Kind {
+	case ScheduleWeekdays:
+		var days []string
+		for day := time.Sunday; day <= time.Saturday; day++ {
+			if s.Weekdays&(1<<day) != 0 {
+				days = append(days, strings.ToLower(day.String()[:3]))
+			}
+		}
+		return strings.Join(days, ",")
+	case ScheduleWeekly, ScheduleMonthly, ScheduleInterval:
+		return strconv.Itoa(s.Count)
+	}
+	return ""
+}
+
+// continuesStreak reports whether a completion on cur extends the streak of
+// the previous completion on prev, i.e. no scheduled period in between was
+// missed. dates holds every completion date of the habit. Days covered by
+// neutral don't count as missed.
+func (s Schedule) continuesStreak(prev, cur time.Time, dates []time.Time, neutral pauses) bool {
+	switch s.Kind {
+	case ScheduleWeekdays:
+		for d := prev.AddDate(0, 0, 1); d.Before(cur); d = d.AddDate(0, 0, 1) {
+			if s.Weekdays&(1<<d.Weekday()) != 0 && !neutral.covers(d) {
+				return false
+			}
+		}
+		return true
+
+	case ScheduleWeekly:
+		week := startOfWeek(prev)
+		next := skipPaused(week.AddDate(0, 0, 7), startOfWeek(cur), neutral, func(t time.Time) time.Time {
+			return t.AddDate(0, 0, 7)
+		})
+		return periodsContinue(week, next, startOfWeek(cur), dates, s.Count)
+
+	case ScheduleMonthly:
+		month := startOfMonth(prev)
+		next := skipPaused(month.AddDate(0, 1, 0), startOfMonth(cur), neutral, func(t time.Time) time.Time {
+			return t.AddDate(0, 1, 0)
+		})
+		return periodsContinue(month, next, startOfMonth(cur), dates, s.Count)
+
+	case ScheduleInterval:
+		return !cur.After(prev.AddDate(0, 0, s.Count+pausedBetween(prev, cur, neutral)))
+	}
+
+	return prev.AddDate(0, 0, 1+pausedBetween(prev, cur, neutral)).Equal(cur)
+}
+
+// pausedBetween counts the paused days strictly between prev and cur
+func pausedBetween(prev, cur time.Time, neutral pauses) int {
+	count := 0
+	for d := prev.AddDate(0, 0, 1); d.Before(cur); d = d.AddDate(0, 0, 1) {
+		if neutral.covers(d) {
+			count++
+		}
+	}
+	return count
+}
+
+// skipPaused moves next past the periods before curStart that are paused
+// from start to end, as if they didn't exist
+func skipPaused(next, curStart time.Time, neutral pauses, following func(time.Time) time.Time) time.Time {
+	for next.Before(curStart) {
+		after := following(next)
+		for d := next; d.Before(after); d = d.AddDate(0, 0, 1) {
+			if !neutral.covers(d) {
+				return next
+			}
+		}
+		next = after
+	}
+	return next
+}
+
+// expected returns how many completions the schedule asks for from the day
+// from through the day to. Weekly and monthly targets are spread evenly, so
+// the result is fractional for ranges that aren't whole periods.
+func (s Schedule) expected(from, to Date) float64 {
+	if to.Before(from) {
+		return 0
+	}
+	days := int(to.Sub(from.Time).Hours()/24+0.5) + 1
+
+	switch s.Kind {
+	case ScheduleWeekdays:
+		count := 0
+		for d := from; !d.After(to); d = d.AddDays(1) {
+			if s.Weekdays&(1<<d.Weekday()) != 0 {
+				count++
+			}
+		}
+		return float64(count)
+	case ScheduleWeekly:
+		return float64(s.Count*days) / 7
+	case ScheduleMonthly:
+		// 365.25 / 12 days in an average month
+		return float64(s.Count*days) / 30.4375
+	case ScheduleInterval:
+		return float64(days) / float64(s.Count)
+	}
+	return float64(days)
+}
+
+// periodsContinue reports whether a streak carries over from the period
+// [start, next) into the period starting at curStart. It does if both are the
+// same period, or if curStart is the following period and the previous one
+// met its target.
+func periodsContinue(start, next, curStart time.Time, dates []time.Time, target int) bool {
+	if curStart.Equal(start) {
+		return true
+	}
+	return curStart.Equal(next) && countBetween(dates, start, next) >= target
+}
+
+func countBetween(dates []time.Time, from, to time.Time) int {
+	count := 0
+	for _, d := range dates {
+		if !d.Before(from) && d.Before(to) {
+			count++
+		}
+	}
+	return count
+}
+
+// weeks start on Monday
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, t.Location())
+}
+
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
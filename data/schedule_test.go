@@ -0,0 +1,146 @@
+package data
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	t.Run("parses specific weekdays", func(t *testing.T) {
+		s, err := ParseSchedule(ScheduleWeekdays, "Mon, wed,friday")
+		didNotExpectError(t, err)
+
+		want := 1<<time.Monday | 1<<time.Wednesday | 1<<time.Friday
+		if s.Weekdays != want {
+			t.Errorf("got %b want %b", s.Weekdays, want)
+		}
+		if s.String() != "Mon, Wed, Fri" {
+			t.Errorf("got %q want %q", s.String(), "Mon, Wed, Fri")
+		}
+	})
+
+	t.Run("parses times per week", func(t *testing.T) {
+		s, err := ParseSchedule(ScheduleWeekly, "3")
+		didNotExpectError(t, err)
+
+		if s.Count != 3 {
+			t.Errorf("got %d want %d", s.Count, 3)
+		}
+	})
+
+	t.Run("rejects invalid arguments", func(t *testing.T) {
+		for _, tc := range []struct {
+			kind ScheduleKind
+			arg  string
+		}{
+			{ScheduleWeekdays, "mon,someday"},
+			{ScheduleWeekly, "8"},
+			{ScheduleMonthly, "zero"},
+			{ScheduleInterval, "0"},
+			{"yearly", "1"},
+		} {
+			_, err := ParseSchedule(tc.kind, tc.arg)
+			if _, ok := err.(*InvalidScheduleError); !ok {
+				t.Errorf("expected invalid schedule error for %s %q, got %v", tc.kind, tc.arg, err)
+			}
+		}
+	})
+}
+
+func TestContinuesStreak(t *testing.T) {
+	day := func(s string) time.Time {
+		t.Helper()
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+
+	weekdays, _ := ParseSchedule(ScheduleWeekdays, "mon,tue,wed,thu,fri")
+	weekly, _ := ParseSchedule(ScheduleWeekly, "2")
+	monthly, _ := ParseSchedule(ScheduleMonthly, "1")
+	interval, _ := ParseSchedule(ScheduleInterval, "3")
+
+	// 2023-01-02 is a Monday
+	cases := []struct {
+		name     string
+		schedule Schedule
+		dates    []string
+		want     bool
+	}{
+		{"daily consecutive days", DailySchedule(), []string{"2023-01-02", "2023-01-03"}, true},
+		{"daily skipped day", DailySchedule(), []string{"2023-01-02", "2023-01-04"}, false},
+		{"weekdays over the weekend", weekdays, []string{"2023-01-06", "2023-01-09"}, true},
+		{"weekdays skipped a weekday", weekdays, []string{"2023-01-05", "2023-01-09"}, false},
+		{"weekly same week", weekly, []string{"2023-01-02", "2023-01-06"}, true},
+		{"weekly target met", weekly, []string{"2023-01-02", "2023-01-08", "2023-01-13"}, true},
+		{"weekly target missed", weekly, []string{"2023-01-08", "2023-01-13"}, false},
+		{"weekly skipped a week", weekly, []string{"2023-01-02", "2023-01-03", "2023-01-16"}, false},
+		{"monthly next month", monthly, []string{"2023-01-31", "2023-02-01"}, true},
+		{"monthly skipped a month", monthly, []string{"2023-01-31", "2023-03-01"}, false},
+		{"interval within range", interval, []string{"2023-01-02", "2023-01-05"}, true},
+		{"interval too late", interval, []string{"2023-01-02", "2023-01-06"}, false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dates := make([]time.Time, len(tc.dates))
+			for i, d := range tc.dates {
+				dates[i] = day(d)
+			}
+
+			prev, cur := dates[len(dates)-2], dates[len(dates)-1]
+			got := tc.schedule.continuesStreak(prev, cur, dates, nil)
+			if got != tc.want {
+				t.Errorf("got %v want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRecordCompletionWithSchedule(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	schedule, _ := ParseSchedule(ScheduleInterval, "3")
+	_, err := g.CreateHabitWithSchedule("water plants", schedule)
+	didNotExpectError(t, err)
+
+	_, err = g.RecordCompletionOn("water plants", testNow.AddDate(0, 0, -5))
+	didNotExpectError(t, err)
+
+	got, err := g.RecordCompletion("water plants")
+	didNotExpectError(t, err)
+	if got.Streak != 1 {
+		t.Errorf("got %d want %d", got.Streak, 1)
+	}
+
+	// filling the gap joins both completions into one streak
+	_, err = g.RecordCompletionOn("water plants", testNow.AddDate(0, 0, -2))
+	didNotExpectError(t, err)
+
+	var todays Completion
+	db.Where("recorded_at = ? AND habit_id = ?", today(), got.HabitID).First(&todays)
+	if todays.Streak != 3 {
+		t.Errorf("got %d want %d", todays.Streak, 3)
+	}
+}
+
+func TestScheduleArg(t *testing.T) {
+	for _, tc := range []struct {
+		kind ScheduleKind
+		arg  string
+	}{
+		{ScheduleDaily, ""},
+		{ScheduleWeekdays, "mon,wed,fri"},
+		{ScheduleWeekly, "3"},
+		{ScheduleInterval, "10"},
+	} {
+		s, err := ParseSchedule(tc.kind, tc.arg)
+		didNotExpectError(t, err)
+		if s.Arg() != tc.arg {
+			t.Errorf("got %q want %q", s.Arg(), tc.arg)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package data
+
+import (
+	"gorm.io/gorm"
+)
+
+// Stats sums up how a habit has been going
+type Stats struct {
+	Habit         string
+	CurrentStreak int
+	LongestStreak int
+	// Total counts the completions that met the habit's target
+	Total int
+	// Rates are the share of scheduled completions that were done, from 0 to
+	// 1, over the last 7, 30 and 365 days and since the habit was created
+	Rate7          float64
+	Rate30         float64
+	Rate365        float64
+	RateSinceStart float64
+}
+
+// GetStats returns the stats of habit, archived or not
+func (d *Database) GetStats(habit string) (Stats, error) {
+	var h Habit
+	err := d.DB.Preload("Records", func(db *gorm.DB) *gorm.DB {
+		return db.Order("recorded_at")
+	}).Preload("Events").Where("name = ?", habit).First(&h).Error
+	if err != nil {
+		return Stats{}, err
+	}
+	return stats(h, d.Today()), nil
+}
+
+// GetActiveStats returns the stats of every active habit
+func (d *Database) GetActiveStats() ([]Stats, error) {
+	return d.GetActiveStatsTagged("")
+}
+
+// GetActiveStatsTagged returns the stats of the active habits tagged with
+// tag, or of all of them if tag is empty
+func (d *Database) GetActiveStatsTagged(tag string) ([]Stats, error) {
+	var habits []Habit
+	err := d.DB.Preload("Records", func(db *gorm.DB) *gorm.DB {
+		return db.Order("recorded_at")
+	}).Preload("Events").Scopes(taggedWith(tag)).Where("active = ?", true).Order("id").Find(&habits).Error
+	if err != nil {
+		return nil, err
+	}
+
+	today := d.Today()
+	result := make([]Stats, len(habits))
+	for i, h := range habits {
+		result[i] = stats(h, today)
+	}
+	return result, nil
+}
+
+// stats works out the stats of h, whose Records are ordered by date
+func stats(h Habit, today Date) Stats {
+	s := Stats{
+		Habit:         h.Name,
+		CurrentStreak: currentStreak(h, h.Records, today),
+	}
+
+	// completions may have been backfilled to before the habit was created
+	start := h.CreatedOn
+	var met []Date
+	for _, c := range h.Records {
+		if !h.met(c) {
+			continue
+		}
+		met = append(met, c.RecordedAt)
+		if c.Streak > s.LongestStreak {
+			s.LongestStreak = c.Streak
+		}
+		if start.IsZero() || c.RecordedAt.Before(start) {
+			start = c.RecordedAt
+		}
+	}
+	s.Total = len(met)
+
+	if start.IsZero() {
+		start = today
+	}
+	s.Rate7 = rate(h.Schedule, met, start, today, 7)
+	s.Rate30 = rate(h.Schedule, met, start, today, 30)
+	s.Rate365 = rate(h.Schedule, met, start, today, 365)
+	s.RateSinceStart = rate(h.Schedule, met, start, today, 0)
+	return s
+}
+
+// rate returns the share of scheduled completions done over the last days
+// up to today, or since start if days is 0 or goes back further than start
+func rate(schedule Schedule, met []Date, start, today Date, days int) float64 {
+	from := start
+	if days > 0 {
+		if window := today.AddDays(1 - days); window.After(start) {
+			from = window
+		}
+	}
+
+	expected := schedule.expected(from, today)
+	if expected == 0 {
+		return 0
+	}
+
+	done := 0
+	for _, d := range met {
+		if !d.Before(from) && !d.After(today) {
+			done++
+		}
+	}
+
+	r := float64(done) / expected
+	if r > 1 {
+		return 1
+	}
+	return r
+}
+
+// Summary is how a habit has been going lately, short enough to show next to
+// it in a list
+type Summary struct {
+	CurrentStreak int
+	// LastWeek is how much of the habit was done on each of the last seven
+	// days, today last, from 0 to 1
+	LastWeek [7]float64
+}
+
+// DoneToday reports whether the habit met its target today
+func (s Summary) DoneToday() bool {
+	return s.LastWeek[len(s.LastWeek)-1] >= 1
+}
+
+// sinceLastMet keeps the completions from 31 days before the last one that
+// met the habit's target on, enough for the week or month the current streak
+// depends on
+const sinceLastMet = `recorded_at >= (
+	SELECT DATE(MAX(met.recorded_at), '-31 days')
+	FROM completions met INNER JOIN habits h ON h.id = met.habit_id
+	WHERE met.habit_id = completions.habit_id AND met.deleted_at IS NULL
+		AND (COALESCE(h.target, 0) <= 0 OR met.amount >= h.target)
+)`
+
+// GetSummaries returns the summaries of the active habits tagged with tag, or
+// of all of them if tag is empty, keyed by name. The habits are loaded
+// together rather than one by one, with only the completions of the last week
+// and those the current streak depends on.
+func (d *Database) GetSummaries(tag string) (map[string]Summary, error) {
+	today := d.Today()
+	first := today.AddDays(-6)
+
+	var habits []Habit
+	err := d.DB.Preload("Records", func(db *gorm.DB) *gorm.DB {
+		return db.Where("recorded_at >= ? OR "+sinceLastMet, first).Order("recorded_at")
+	}).Preload("Events").Scopes(taggedWith(tag)).Where("active = ?", true).Find(&habits).Error
+	if err != nil {
+		return nil, err
+	}
+
+	days := map[Date]int{}
+	for i := 0; i < 7; i++ {
+		days[first.AddDays(i)] = i
+	}
+
+	summaries := make(map[string]Summary, len(habits))
+	for _, h := range habits {
+		s := Summary{CurrentStreak: currentStreak(h, h.Records, today)}
+		for _, c := range h.Records {
+			if i, ok := days[c.RecordedAt]; ok {
+				s.LastWeek[i] = h.progress(c)
+			}
+		}
+		summaries[h.Name] = s
+	}
+	return summaries, nil
+}
+
+// progress is the share of h's target that c met, 1 for habits without one
+func (h Habit) progress(c Completion) float64 {
+	if h.met(c) {
+		return 1
+	}
+	return c.Amount / h.Target
+}
@@ -0,0 +1,161 @@
+package data
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestGetStats(t *testing.T) {
+	db := setup(t)
+	clock := &fakeClock{now: time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	g := Database{DB: db, Calendar: Calendar{Location: time.UTC}, Clock: clock}
+
+	_, err := g.CreateHabit("stretch")
+	didNotExpectError(t, err)
+
+	// a week in a row, a break and another four days up to today
+	clock.now = time.Date(2024, time.March, 15, 12, 0, 0, 0, time.UTC)
+	for _, day := range []int{1, 2, 3, 4, 5, 6, 7, 12, 13, 14, 15} {
+		_, err := g.RecordCompletionOn("stretch", time.Date(2024, time.March, day, 0, 0, 0, 0, time.UTC))
+		didNotExpectError(t, err)
+	}
+
+	got, err := g.GetStats("stretch")
+	didNotExpectError(t, err)
+
+	if got.CurrentStreak != 4 {
+		t.Errorf("got current streak %d want %d", got.CurrentStreak, 4)
+	}
+	if got.LongestStreak != 7 {
+		t.Errorf("got longest streak %d want %d", got.LongestStreak, 7)
+	}
+	if got.Total != 11 {
+		t.Errorf("got total %d want %d", got.Total, 11)
+	}
+
+	// the longer windows only go back to the day the habit was created
+	for _, tc := range []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"7 days", got.Rate7, 4.0 / 7},
+		{"30 days", got.Rate30, 11.0 / 15},
+		{"365 days", got.Rate365, 11.0 / 15},
+		{"since creation", got.RateSinceStart, 11.0 / 15},
+	} {
+		if math.Abs(tc.got-tc.want) > 1e-9 {
+			t.Errorf("got %s rate %v want %v", tc.name, tc.got, tc.want)
+		}
+	}
+
+	_, err = g.GetStats("fly")
+	assertRecordNotFound(t, err)
+}
+
+func TestGetActiveStats(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	got, err := g.GetActiveStats()
+	didNotExpectError(t, err)
+	if len(got) != 4 {
+		t.Fatalf("got stats of %d habits want %d", len(got), 4)
+	}
+
+	// play guitar was done today, years after its streak of 20
+	guitar := got[3]
+	if guitar.Habit != "play guitar" || guitar.CurrentStreak != 1 || guitar.LongestStreak != 20 {
+		t.Errorf("got %+v want play guitar on a streak of 1, longest 20", guitar)
+	}
+	if guitar.Rate7 != 1.0/7 {
+		t.Errorf("got 7 day rate %v want %v", guitar.Rate7, 1.0/7)
+	}
+}
+
+func TestGetSummaries(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	_, err := g.CreateQuantitativeHabit("drink water", DailySchedule(), 8, "glasses")
+	didNotExpectError(t, err)
+	_, err = g.RecordAmount("drink water", 2)
+	didNotExpectError(t, err)
+
+	got, err := g.GetSummaries("")
+	didNotExpectError(t, err)
+	if len(got) != 5 {
+		t.Fatalf("got summaries of %d habits want %d", len(got), 5)
+	}
+
+	for _, tc := range []struct {
+		habit     string
+		streak    int
+		lastWeek  [7]float64
+		doneToday bool
+	}{
+		{"cook", 3, [7]float64{0, 0, 0, 0, 0, 1, 0}, false},
+		{"read", 0, [7]float64{0, 0, 1, 1, 1, 0, 0}, false},
+		{"play guitar", 1, [7]float64{0, 0, 0, 0, 0, 0, 1}, true},
+		{"drink water", 0, [7]float64{0, 0, 0, 0, 0, 0, 0.25}, false},
+	} {
+		s := got[tc.habit]
+		if s.CurrentStreak != tc.streak || s.LastWeek != tc.lastWeek || s.DoneToday() != tc.doneToday {
+			t.Errorf("got %+v for %s want streak %d and last week %v", s, tc.habit, tc.streak, tc.lastWeek)
+		}
+	}
+
+	t.Run("keeps streaks that depend on completions before last week", func(t *testing.T) {
+		// kept going across a pause of five weeks
+		_, err := g.CreateHabit("swim")
+		didNotExpectError(t, err)
+		for _, days := range []int{41, 40} {
+			_, err := g.RecordCompletionOn("swim", testNow.AddDate(0, 0, -days))
+			didNotExpectError(t, err)
+		}
+		didNotExpectError(t, g.ArchiveHabitOn("swim", testNow.AddDate(0, 0, -39)))
+		didNotExpectError(t, g.RestoreHabitOn("swim", testNow, true))
+
+		// twice a week, met last week
+		twice, _ := ParseSchedule(ScheduleWeekly, "2")
+		_, err = g.CreateHabitWithSchedule("stretch", twice)
+		didNotExpectError(t, err)
+		for _, days := range []int{11, 10} {
+			_, err := g.RecordCompletionOn("stretch", testNow.AddDate(0, 0, -days))
+			didNotExpectError(t, err)
+		}
+
+		got, err := g.GetSummaries("")
+		didNotExpectError(t, err)
+		for _, habit := range []string{"swim", "stretch"} {
+			want, err := g.CurrentStreak(habit)
+			didNotExpectError(t, err)
+			if want == 0 || got[habit].CurrentStreak != want {
+				t.Errorf("got streak %d for %s want %d", got[habit].CurrentStreak, habit, want)
+			}
+		}
+	})
+}
+
+func TestScheduleExpected(t *testing.T) {
+	weekdays, _ := ParseSchedule(ScheduleWeekdays, "mon,tue,wed,thu,fri")
+	weekly, _ := ParseSchedule(ScheduleWeekly, "3")
+	interval, _ := ParseSchedule(ScheduleInterval, "2")
+
+	// 2023-01-02 is a Monday
+	from, to := NewDate(2023, time.January, 2), NewDate(2023, time.January, 15)
+	for _, tc := range []struct {
+		schedule Schedule
+		want     float64
+	}{
+		{DailySchedule(), 14},
+		{weekdays, 10},
+		{weekly, 6},
+		{interval, 7},
+	} {
+		if got := tc.schedule.expected(from, to); got != tc.want {
+			t.Errorf("got %v for %s want %v", got, tc.schedule, tc.want)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package data
+
+import (
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Tag groups habits, e.g. "health" or "work". A habit can have many tags and
+// a tag many habits.
+type Tag struct {
+	ID   uint
+	Name string `gorm:"unique;not null"`
+}
+
+// habitTag links a habit to one of its tags
+type habitTag struct {
+	HabitID uint `gorm:"primaryKey"`
+	TagID   uint `gorm:"primaryKey"`
+}
+
+func (habitTag) TableName() string {
+	return "habit_tags"
+}
+
+// ParseTags splits a comma separated list of tags. Tags are lowercased and
+// sorted, and empty and repeated ones are dropped.
+func ParseTags(s string) []string {
+	seen := map[string]bool{}
+	var tags []string
+	for _, t := range strings.Split(s, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// TagNames returns the names of h's tags. It needs the habit's Tags to be
+// loaded.
+func (h Habit) TagNames() []string {
+	names := make([]string, len(h.Tags))
+	for i, t := range h.Tags {
+		names[i] = t.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+// taggedWith limits a query on habits to those tagged with tag. An empty tag
+// doesn't limit it.
+func taggedWith(tag string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if tag == "" {
+			return db
+		}
+		tagged := db.Session(&gorm.Session{NewDB: true}).Table("habit_tags").
+			Select("habit_tags.habit_id").
+			Joins("INNER JOIN tags ON tags.id = habit_tags.tag_id").
+			Where("tags.name = ?", strings.ToLower(tag))
+		return db.Where("habits.id IN (?)", tagged)
+	}
+}
+
+// SetHabitTags replaces the tags of habit with tags, see ParseTags
+func (d *Database) SetHabitTags(habit string, tags []string) error {
+	return d.DB.Transaction(func(tx *gorm.DB) error {
+		var h Habit
+		if err := tx.Where("name = ?", habit).First(&h).Error; err != nil {
+			return err
+		}
+		return setTags(tx, &h, tags)
+	})
+}
+
+func setTags(tx *gorm.DB, h *Habit, names []string) error {
+	tags := make([]Tag, len(names))
+	for i, name := range names {
+		if err := tx.Where(Tag{Name: name}).FirstOrCreate(&tags[i]).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Model(h).Association("Tags").Replace(tags); err != nil {
+		return err
+	}
+	return deleteUnusedTags(tx)
+}
+
+func deleteUnusedTags(tx *gorm.DB) error {
+	return tx.Exec("DELETE FROM tags WHERE id NOT IN (SELECT tag_id FROM habit_tags)").Error
+}
+
+// GetTags returns the tags of all habits that haven't been deleted, sorted by
+// name
+func (d *Database) GetTags() ([]string, error) {
+	var tags []string
+	err := d.DB.Table("tags").
+		Distinct("tags.name").
+		Joins("INNER JOIN habit_tags ON habit_tags.tag_id = tags.id").
+		Joins("INNER JOIN habits ON habits.id = habit_tags.habit_id AND habits.deleted_at IS NULL").
+		Order("tags.name").
+		Pluck("tags.name", &tags).Error
+	return tags, err
+}
+
+// GetActiveHabitsTagged returns the active habits tagged with tag, or all of
+// them if tag is empty
+func (d *Database) GetActiveHabitsTagged(tag string) []Habit {
+	return d.getHabits(true, tag)
+}
+
+// GetInactiveHabitsTagged returns the archived habits tagged with tag, or all
+// of them if tag is empty
+func (d *Database) GetInactiveHabitsTagged(tag string) []Habit {
+	return d.getHabits(false, tag)
+}
@@ -0,0 +1,160 @@
+package data
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	got := ParseTags(" Work, health,,HEALTH , ")
+	want := []string{"health", "work"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	if got := ParseTags(""); len(got) != 0 {
+		t.Errorf("got %v want no tags", got)
+	}
+}
+
+func TestSetHabitTags(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	didNotExpectError(t, g.SetHabitTags("cook", []string{"food", "health"}))
+	didNotExpectError(t, g.SetHabitTags("read", []string{"health"}))
+
+	t.Run("tags habits", func(t *testing.T) {
+		cook, err := g.GetHabit("cook")
+		didNotExpectError(t, err)
+		if got := cook.TagNames(); !reflect.DeepEqual(got, []string{"food", "health"}) {
+			t.Errorf("got tags %v want [food health]", got)
+		}
+
+		tags, err := g.GetTags()
+		didNotExpectError(t, err)
+		if !reflect.DeepEqual(tags, []string{"food", "health"}) {
+			t.Errorf("got tags %v want [food health]", tags)
+		}
+	})
+
+	t.Run("filters habits by tag", func(t *testing.T) {
+		got := habitNames(g.GetActiveHabitsTagged("health"))
+		if !reflect.DeepEqual(got, []string{"cook", "read"}) {
+			t.Errorf("got %v want [cook read]", got)
+		}
+		if got := g.GetActiveHabitsTagged(""); len(got) != 4 {
+			t.Errorf("got %d habits without a tag want %d", len(got), 4)
+		}
+		if got := g.GetInactiveHabitsTagged("health"); len(got) != 0 {
+			t.Errorf("got %v want no archived habits tagged health", habitNames(got))
+		}
+
+		stats, err := g.GetActiveStatsTagged("food")
+		didNotExpectError(t, err)
+		if len(stats) != 1 || stats[0].Habit != "cook" {
+			t.Errorf("got %+v want the stats of cook", stats)
+		}
+
+		between := habitNames(g.GetHabitsActiveBetween(today(), today(), false, "health"))
+		if !reflect.DeepEqual(between, []string{"cook", "read"}) {
+			t.Errorf("got %v want [cook read]", between)
+		}
+	})
+
+	t.Run("replaces tags and forgets unused ones", func(t *testing.T) {
+		didNotExpectError(t, g.SetHabitTags("cook", nil))
+
+		tags, err := g.GetTags()
+		didNotExpectError(t, err)
+		if !reflect.DeepEqual(tags, []string{"health"}) {
+			t.Errorf("got tags %v want [health]", tags)
+		}
+		var count int64
+		db.Model(&Tag{}).Count(&count)
+		if count != 1 {
+			t.Errorf("got %d tags stored want %d", count, 1)
+		}
+	})
+
+	t.Run("deleting a habit removes its tags", func(t *testing.T) {
+		didNotExpectError(t, g.DeleteHabit("read", true))
+
+		tags, err := g.GetTags()
+		didNotExpectError(t, err)
+		if len(tags) != 0 {
+			t.Errorf("got tags %v want none", tags)
+		}
+	})
+
+	t.Run("returns an error for unknown habits", func(t *testing.T) {
+		assertRecordNotFound(t, g.SetHabitTags("fly", []string{"sky"}))
+	})
+}
+
+func TestCreateTaggedHabit(t *testing.T) {
+	db := setup(t)
+	g := Database{DB: db, Clock: testClock}
+
+	t.Run("creates a habit with its tags and reminder", func(t *testing.T) {
+		_, err := g.CreateTaggedHabit(Habit{Name: "swim", Target: 2, Unit: "km", RemindAt: "7:00"}, []string{"health"})
+		didNotExpectError(t, err)
+
+		h, err := g.GetHabit("swim")
+		didNotExpectError(t, err)
+		if !reflect.DeepEqual(h.TagNames(), []string{"health"}) || h.RemindAt != "07:00" {
+			t.Errorf("got tags %v and reminder %q want [health] and 07:00", h.TagNames(), h.RemindAt)
+		}
+		if !h.Active || !h.CreatedOn.Equal(today()) || h.Schedule != DailySchedule() || h.Target != 2 {
+			t.Errorf("got %+v want an active daily habit created today", h)
+		}
+	})
+
+	t.Run("keeps the description and colour", func(t *testing.T) {
+		_, err := g.CreateTaggedHabit(Habit{Name: "stretch", Description: " before breakfast ", Color: "#FF8800"}, nil)
+		didNotExpectError(t, err)
+
+		h, err := g.GetHabit("stretch")
+		didNotExpectError(t, err)
+		if h.Description != "before breakfast" || h.Color != "#ff8800" {
+			t.Errorf("got description %q and colour %q want %q and %q", h.Description, h.Color, "before breakfast", "#ff8800")
+		}
+	})
+
+	t.Run("does not create anything with an invalid colour", func(t *testing.T) {
+		_, err := g.CreateTaggedHabit(Habit{Name: "run", Color: "orange"}, nil)
+		if _, ok := err.(*InvalidColorError); !ok {
+			t.Errorf("expected invalid colour error, got %v", err)
+		}
+		_, err = g.GetHabit("run")
+		assertRecordNotFound(t, err)
+	})
+
+	t.Run("does not create anything with an invalid reminder", func(t *testing.T) {
+		_, err := g.CreateTaggedHabit(Habit{Name: "run", RemindAt: "noon"}, nil)
+		if _, ok := err.(*InvalidReminderError); !ok {
+			t.Errorf("expected invalid reminder error, got %v", err)
+		}
+		_, err = g.GetHabit("run")
+		assertRecordNotFound(t, err)
+	})
+
+	t.Run("does not leave the habit behind when tagging fails", func(t *testing.T) {
+		didNotExpectError(t, db.Migrator().DropTable(&Tag{}))
+
+		_, err := g.CreateTaggedHabit(Habit{Name: "run"}, []string{"health"})
+		if err == nil {
+			t.Fatalf("expected an error without a tags table")
+		}
+		_, err = g.GetHabit("run")
+		assertRecordNotFound(t, err)
+	})
+}
+
+func habitNames(habits []Habit) []string {
+	var names []string
+	for _, h := range habits {
+		names = append(names, h.Name)
+	}
+	return names
+}
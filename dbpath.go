@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// resolveDBPath picks the database file. In order of precedence that is the
+// --db flag, the HABITS_DB environment variable, and otherwise a file named
+// after the profile under $XDG_DATA_HOME/habits. The profile is "demo" when
+// DEMO=true and "habits" otherwise. The parent directory is created if
+// needed.
+func resolveDBPath(dbFlag string, getenv func(string) string) (string, error) {
+	path := dbFlag
+	if path == "" {
+		path = getenv("HABITS_DB")
+	}
+
+	if path == "" {
+		dir, err := dataDir(getenv)
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(dir, profile(getenv)+".db")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+func profile(getenv func(string) string) string {
+	if getenv("DEMO") == "true" {
+		return "demo"
+	}
+	return "habits"
+}
+
+// dataDir follows the XDG base directory spec, falling back to
+// ~/.local/share when XDG_DATA_HOME is not set
+func dataDir(getenv func(string) string) (string, error) {
+	base := getenv("XDG_DATA_HOME")
+	if base == "" || !filepath.IsAbs(base) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(home, ".local", "share")
+	}
+	return filepath.Join(base, "habits"), nil
+}
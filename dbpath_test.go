@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveDBPath(t *testing.T) {
+	dir := t.TempDir()
+	home := filepath.Join(dir, "home")
+	t.Setenv("HOME", home)
+	xdg := filepath.Join(dir, "xdg")
+
+	tests := []struct {
+		name   string
+		dbFlag string
+		env    map[string]string
+		want   string
+	}{
+		{
+			name:   "flag",
+			dbFlag: filepath.Join(dir, "flag.db"),
+			env:    map[string]string{"HABITS_DB": filepath.Join(dir, "env.db"), "XDG_DATA_HOME": xdg},
+			want:   filepath.Join(dir, "flag.db"),
+		},
+		{
+			name: "HABITS_DB",
+			env:  map[string]string{"HABITS_DB": filepath.Join(dir, "env.db"), "XDG_DATA_HOME": xdg},
+			want: filepath.Join(dir, "env.db"),
+		},
+		{
+			name: "XDG_DATA_HOME",
+			env:  map[string]string{"XDG_DATA_HOME": xdg},
+			want: filepath.Join(xdg, "habits", "habits.db"),
+		},
+		{
+			name: "home",
+			env:  map[string]string{},
+			want: filepath.Join(home, ".local", "share", "habits", "habits.db"),
+		},
+		{
+			name: "relative XDG_DATA_HOME is ignored",
+			env:  map[string]string{"XDG_DATA_HOME": "xdg"},
+			want: filepath.Join(home, ".local", "share", "habits", "habits.db"),
+		},
+		{
+			name: "demo profile",
+			env:  map[string]string{"XDG_DATA_HOME": xdg, "DEMO": "true"},
+			want: filepath.Join(xdg, "habits", "demo.db"),
+		},
+		{
+			name: "demo profile only without an explicit path",
+			env:  map[string]string{"HABITS_DB": filepath.Join(dir, "env.db"), "DEMO": "true"},
+			want: filepath.Join(dir, "env.db"),
+		},
+		{
+			name:   "creates parent directories",
+			dbFlag: filepath.Join(dir, "a", "b", "habits.db"),
+			env:    map[string]string{},
+			want:   filepath.Join(dir, "a", "b", "habits.db"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveDBPath(tt.dbFlag, getenvFrom(tt.env))
+			if err != nil {
+				t.Fatalf("did not expect an error, got %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("got %q want %q", got, tt.want)
+			}
+			if info, err := os.Stat(filepath.Dir(got)); err != nil || !info.IsDir() {
+				t.Errorf("expected %s to be created, got %v", filepath.Dir(got), err)
+			}
+		})
+	}
+}
+
+// getenvFrom looks up environment variables in env instead of the process
+// environment
+func getenvFrom(env map[string]string) func(string) string {
+	return func(key string) string {
+		return env[key]
+	}
+}
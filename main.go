@@ -1,6 +1,8 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"os"
 
@@ -12,32 +14,54 @@ import (
 	"gorm.io/gorm/logger"
 )
 
-func openSQLite() *gorm.DB {
-	var dbName string
-	if os.Getenv("DEMO") == "true" {
-		dbName = "demo.db"
-	} else {
-		dbName = "habits.db"
-	}
-
+func openSQLite(dbName string) *gorm.DB {
 	db, err := gorm.Open(sqlite.Open(dbName),
 		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
 	if err != nil {
 		log.Fatalf("unable to open database: %v", err)
 	}
+	return db
+}
 
-	err = db.AutoMigrate(&data.Habit{}, &data.Completion{})
+func migrate(db *gorm.DB) {
+	backup, err := data.Migrate(db)
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("unable to migrate database: %v", err)
+	}
+	if backup != "" {
+		fmt.Fprintf(os.Stderr, "habits: migrated the database, the previous version is kept at %s\n", backup)
 	}
-	return db
 }
 
 func main() {
+	dbFlag := flag.String("db", "", "path of the database file")
+	tzFlag := flag.String("tz", "", "home timezone, like Europe/Berlin")
+	dayStartFlag := flag.String("day-start", "", "hour of the day new days start at")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+	}
+	flag.Parse()
 
-	db := openSQLite()
+	dbName, err := resolveDBPath(*dbFlag, os.Getenv)
+	if err != nil {
+		log.Fatalf("unable to locate database: %v", err)
+	}
+
+	calendar, err := resolveCalendar(*tzFlag, *dayStartFlag, os.Getenv)
+	if err != nil {
+		log.Fatalf("unable to configure days: %v", err)
+	}
+
+	db := openSQLite(dbName)
+	if !managesMigrations(flag.Args()) {
+		migrate(db)
+	}
+
+	if flag.NArg() > 0 {
+		os.Exit(runCommand(data.Database{DB: db, Calendar: calendar}, flag.Args(), os.Stdout, os.Stderr))
+	}
 
-	p := tea.NewProgram(pages.NewList(db))
+	p := tea.NewProgram(pages.NewList(data.Database{DB: db, Calendar: calendar}))
 
 	if _, err := p.Run(); err != nil {
 		log.Fatal(err)
@@ -11,19 +11,21 @@ type ArchivedHabitsModel struct {
 	list      list.Model
 	listModel ListModel
 	choice    string
+	// confirming is set while asking whether to delete choice
+	confirming bool
+	err        error
 }
 
 func NewArchivedHabitsModel(listModel ListModel) ArchivedHabitsModel {
-	archivedHabits := listModel.db.GetInactiveHabits()
+	archivedHabits := listModel.db.GetInactiveHabitsTagged(listModel.tag)
 
 	items := itemsToList(archivedHabits)
 
 	const defaultWidth = 200
 
-	l := list.New(items, itemDelegate{}, defaultWidth, listHeight)
+	l := list.New(items, habitDelegate(archivedHabits), defaultWidth, listHeight)
 	l.Title = "What habit do you want to restore and track again?"
 	l.SetShowStatusBar(false)
-	l.SetFilteringEnabled(false)
 	l.Styles.Title = titleStyle
 	l.Styles.PaginationStyle = paginationStyle
 	l.Styles.HelpStyle = helpStyle
@@ -49,26 +51,33 @@ func (m ArchivedHabitsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.list.SetWidth(msg.Width)
 		return m, nil
 	case tea.KeyMsg:
+		if m.confirming {
+			return m.confirmDelete(msg)
+		}
+		// while a filter is typed the keys are part of it, not commands
+		if m.list.FilterState() == list.Filtering && msg.Type != tea.KeyCtrlC {
+			break
+		}
 		switch msg.Type {
 		case tea.KeyCtrlC:
 			return m, tea.Quit
 		case tea.KeyEnter:
-			i, ok := m.list.SelectedItem().(item)
-			if ok {
-				m.choice = string(i)
-			}
-
-			err := m.listModel.db.RestoreHabit(m.choice)
-			if err != nil {
-				fmt.Println(err)
-			}
-			restored := restoredHabitMsg{
-				choice: m.choice,
-			}
-			return m.listModel.Update(restored)
+			return m.restore(false)
 		case tea.KeyCtrlO:
 			return m.listModel.Update(nil)
-
+		case tea.KeyRunes:
+			switch msg.String() {
+			case "s":
+				// the days it was archived don't break the streak
+				return m.restore(true)
+			case "d":
+				if name, ok := selectedHabit(m.list); ok {
+					m.choice = name
+					m.confirming = true
+					m.err = nil
+				}
+				return m, nil
+			}
 		}
 	}
 
@@ -76,14 +85,61 @@ func (m ArchivedHabitsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m ArchivedHabitsModel) restore(keepStreak bool) (tea.Model, tea.Cmd) {
+	if name, ok := selectedHabit(m.list); ok {
+		m.choice = name
+	}
+
+	err := m.listModel.db.RestoreHabitOn(m.choice, m.listModel.db.Today().Time, keepStreak)
+	if err != nil {
+		fmt.Println(err)
+	}
+	restored := restoredHabitMsg{
+		choice: m.choice,
+	}
+	return m.listModel.Update(restored)
+}
+
+// confirmDelete deletes the chosen habit on y and goes back to the list of
+// archived habits on anything else
+func (m ArchivedHabitsModel) confirmDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.confirming = false
+	if msg.Type == tea.KeyCtrlC {
+		return m, tea.Quit
+	}
+	if msg.String() != "y" {
+		return m, nil
+	}
+
+	if err := m.listModel.db.DeleteHabit(m.choice, true); err != nil {
+		m.err = err
+		return m, nil
+	}
+	return m.listModel.Update(deletedHabitMsg{choice: m.choice})
+}
+
 func (m ArchivedHabitsModel) View() string {
-	return m.list.View() + m.helpView()
+	var s string
+	if m.confirming {
+		s = notificationTextStyle.Render(fmt.Sprintf(
+			"Delete %s and all of its completions for good? This cannot be undone.", m.choice,
+		))
+		return filteredListView(m.list) + "\n" + s + helpStyle.Render("\n y: delete • n: cancel\n")
+	}
+	if m.err != nil {
+		s = "\n" + notificationTextStyle.Render(fmt.Sprintf("Could not delete %s: %s", m.choice, m.err))
+	}
+	return filteredListView(m.list) + s + m.helpView()
 }
 
 func (m ArchivedHabitsModel) helpView() string {
-	return helpStyle.Render("\n ↑/k: up • ↓/j: down • ctrl+c: quit • ctrl+o: back • enter: restore\n")
+	return helpStyle.Render("\n ↑/k: up • ↓/j: down • /: search • ctrl+c: quit • ctrl+o: back • enter: restore • s: restore keeping the streak • d: delete\n")
 }
 
 type restoredHabitMsg struct {
 	choice string
 }
+
+type deletedHabitMsg struct {
+	choice string
+}
@@ -0,0 +1,96 @@
+package pages
+
+import (
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// how many days back the backfill picker offers
+const backfillDays = 30
+
+type BackfillModel struct {
+	list      list.Model
+	listModel ListModel
+	habit     string
+}
+
+func NewBackfillModel(listModel ListModel, habit string) BackfillModel {
+	days := make([]list.Item, backfillDays)
+	for i := 0; i < backfillDays; i++ {
+		day := listModel.db.Today().AddDays(-(i + 1)).String()
+		days[i] = list.Item(item(day))
+	}
+
+	l := list.New(days, itemDelegate{}, defaultWidth, listHeight)
+	l.Title = "On what day did you complete " + habit + "?"
+	l.SetShowStatusBar(false)
+	l.SetFilteringEnabled(false)
+	l.Styles.Title = titleStyle
+	l.Styles.PaginationStyle = paginationStyle
+	l.Styles.HelpStyle = helpStyle
+	l.SetShowHelp(false)
+
+	return BackfillModel{
+		list:      l,
+		listModel: listModel,
+		habit:     habit,
+	}
+}
+
+type backfilledMsg struct {
+	choice string
+	date   string
+	streak int
+	err    error
+}
+
+func (m BackfillModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m BackfillModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.list.SetWidth(msg.Width)
+		return m, nil
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return m, tea.Quit
+		case tea.KeyEnter:
+			i, ok := m.list.SelectedItem().(item)
+			if !ok {
+				return m, nil
+			}
+
+			backfilled := backfilledMsg{choice: m.habit, date: string(i)}
+			date, err := time.Parse("2006-01-02", string(i))
+			if err != nil {
+				backfilled.err = err
+				return m.listModel.Update(backfilled)
+			}
+
+			completion, err := m.listModel.db.RecordCompletionOn(m.habit, date)
+			backfilled.err = err
+			backfilled.streak = completion.Streak
+			return m.listModel.Update(backfilled)
+		case tea.KeyCtrlO:
+			return m.listModel.Update(nil)
+		}
+	}
+
+	m.list, cmd = m.list.Update(msg)
+	return m, cmd
+}
+
+func (m BackfillModel) View() string {
+	return m.list.View() + m.helpView()
+}
+
+func (m BackfillModel) helpView() string {
+	return helpStyle.Render("\n ↑/k: up • ↓/j: down • ctrl+c: quit • ctrl+o: back • enter: record\n")
+}
@@ -0,0 +1,151 @@
+package pages
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bodowd/habits/data"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// the fields of the edit form
+const (
+	editNameField = iota
+	editDescriptionField
+	editScheduleKindField
+	editScheduleArgField
+	editColorField
+	editTagsField
+	editReminderField
+)
+
+var editFieldLabels = []string{"Name", "Description", "Schedule", "Schedule details", "Colour", "Tags", "Reminder"}
+
+type EditHabitModel struct {
+	listModel ListModel
+	habit     data.Habit
+	inputs    []textinput.Model
+	focus     int
+	err       error
+}
+
+func NewEditHabitModel(listModel ListModel, habit data.Habit) EditHabitModel {
+	values := []string{
+		habit.Name,
+		habit.Description,
+		string(habit.Schedule.Kind),
+		habit.Schedule.Arg(),
+		habit.Color,
+		strings.Join(habit.TagNames(), ", "),
+		habit.RemindAt,
+	}
+	placeholders := []string{
+		"Enter habit",
+		"What is it about?",
+		"daily, weekdays, weekly, monthly or interval",
+		"depends on the schedule",
+		"#ff8800 or 0-255",
+		"e.g. health, work",
+		"HH:MM, empty for none",
+	}
+
+	inputs := make([]textinput.Model, len(values))
+	for i := range inputs {
+		ti := textinput.New()
+		ti.Placeholder = placeholders[i]
+		ti.CharLimit = 156
+		ti.Width = 40
+		ti.SetValue(values[i])
+		inputs[i] = ti
+	}
+	inputs[editNameField].Focus()
+
+	return EditHabitModel{listModel: listModel, habit: habit, inputs: inputs}
+}
+
+type editedHabitMsg struct {
+	from string
+	to   string
+}
+
+func (m EditHabitModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m EditHabitModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "ctrl+o":
+			return m.listModel.Update(nil)
+		case "tab", "down":
+			return m.focusField((m.focus + 1) % len(m.inputs)), nil
+		case "shift+tab", "up":
+			return m.focusField((m.focus + len(m.inputs) - 1) % len(m.inputs)), nil
+		case "enter":
+			return m.save()
+		}
+	}
+
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+func (m EditHabitModel) focusField(i int) EditHabitModel {
+	m.inputs[m.focus].Blur()
+	m.focus = i
+	m.inputs[m.focus].Focus()
+	return m
+}
+
+func (m EditHabitModel) save() (tea.Model, tea.Cmd) {
+	kind := data.ScheduleKind(strings.ToLower(strings.TrimSpace(m.inputs[editScheduleKindField].Value())))
+	schedule, err := data.ParseSchedule(kind, m.inputs[editScheduleArgField].Value())
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+
+	h, err := m.listModel.db.EditHabit(m.habit.Name, data.HabitEdit{
+		Name:        m.inputs[editNameField].Value(),
+		Description: m.inputs[editDescriptionField].Value(),
+		Schedule:    schedule,
+		Color:       m.inputs[editColorField].Value(),
+		Tags:        data.ParseTags(m.inputs[editTagsField].Value()),
+		RemindAt:    m.inputs[editReminderField].Value(),
+	})
+	if err != nil {
+		m.err = err
+		return m, nil
+	}
+	return m.listModel.Update(editedHabitMsg{from: m.habit.Name, to: h.Name})
+}
+
+func (m EditHabitModel) View() string {
+	s := fmt.Sprintf("Edit %s\n\n", m.habit.Name)
+	for i, input := range m.inputs {
+		label := fmt.Sprintf("%-17s", editFieldLabels[i])
+		if i == m.focus {
+			label = selectedItemStyle.Render("> " + label)
+		} else {
+			label = itemStyle.Render(label)
+		}
+		s += label + " " + input.View() + "\n"
+	}
+
+	if color, err := data.ParseColor(m.inputs[editColorField].Value()); err == nil && color != "" {
+		s += "\n" + itemStyle.Render(lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render("■ "+m.inputs[editNameField].Value())) + "\n"
+	}
+
+	s += helpStyle.Render("\n tab/↓: next field • shift+tab/↑: previous field • ctrl+c: quit • ctrl+o: back • enter: save\n")
+
+	if m.err != nil {
+		s += fmt.Sprintf("Error: %s", m.err.Error())
+	}
+	return s
+}
@@ -3,12 +3,16 @@ package pages
 import (
 	"fmt"
 	"io"
+	"math"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/bodowd/habits/data"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"gorm.io/gorm"
 )
 
 const listHeight = 15
@@ -24,20 +28,68 @@ var (
 
 type item string
 
-func (i item) FilterValue() string { return "" }
+func (i item) FilterValue() string { return string(i) }
 
-type itemDelegate struct{}
+// habitItem is a habit in a list. Filtering the list matches its name, tags
+// and description.
+type habitItem struct {
+	name        string
+	description string
+	tags        []string
+}
+
+func (i habitItem) FilterValue() string {
+	return strings.Join(append(append([]string{i.name}, i.tags...), i.description), " ")
+}
+
+// selectedHabit returns the name of the habit selected in l
+func selectedHabit(l list.Model) (string, bool) {
+	i, ok := l.SelectedItem().(habitItem)
+	return i.name, ok
+}
+
+// itemDelegate renders numbered items. Items found in colors are shown in
+// that colour, items found in summaries with how they have been going.
+type itemDelegate struct {
+	colors    map[string]string
+	summaries map[string]data.Summary
+	// marked items show as about to be checked off
+	marked map[string]bool
+	// nameWidth lines up the summaries after names of different lengths
+	nameWidth int
+}
 
 func (d itemDelegate) Height() int                               { return 1 }
 func (d itemDelegate) Spacing() int                              { return 0 }
 func (d itemDelegate) Update(msg tea.Msg, m *list.Model) tea.Cmd { return nil }
 func (d itemDelegate) Render(w io.Writer, m list.Model, index int, listItem list.Item) {
-	i, ok := listItem.(item)
-	if !ok {
+	var name string
+	switch i := listItem.(type) {
+	case item:
+		name = string(i)
+	case habitItem:
+		name = i.name
+	default:
 		return
 	}
 
-	str := fmt.Sprintf("%d. %s", index+1, i)
+	summary, hasSummary := d.summaries[name]
+	label := name
+	if hasSummary {
+		label = fmt.Sprintf("%-*s", d.nameWidth, name)
+	}
+	if color, ok := d.colors[name]; ok {
+		label = lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(label)
+	}
+	str := fmt.Sprintf("%d. %s", index+1, label)
+	if hasSummary {
+		box := checkbox(summary)
+		if d.marked[name] && !summary.DoneToday() {
+			box = "[+]"
+		}
+		str = fmt.Sprintf("%d. %s %s %s  streak %d",
+			index+1, box, label, sparkline(summary.LastWeek), summary.CurrentStreak)
+	}
 
 	fn := itemStyle.Render
 	if index == m.Index() {
@@ -57,15 +109,45 @@ type ListModel struct {
 	errorMessage       string
 	streak             int
 	StatusMessageFlags StatusMessageFlags
+	// completions recorded this session, most recent last, so they can be undone
+	recorded []recordedCompletion
+	// tag limits the habits shown to those tagged with it, empty for all
+	tag string
+	// delegate renders the habits with their summaries
+	delegate itemDelegate
+	// marked are the habits selected to be checked off together
+	marked map[string]bool
+}
+
+type recordedCompletion struct {
+	habit string
+	date  time.Time
+	// amount added to a quantitative habit, zero for plain completions
+	amount float64
 }
 
 type StatusMessageFlags struct {
-	newEntry        string
-	quitting        bool
-	alreadyRecorded bool
-	newRecord       bool
-	archived        bool
-	restoredHabit   string
+	newEntry       string
+	quitting       bool
+	unmarked       bool
+	recordError    error
+	recordedMany   []string
+	nothingNew     bool
+	newRecord      bool
+	archived       bool
+	restoredHabit  string
+	backfilledDate string
+	backfillError  error
+	undone         string
+	nothingToUndo  bool
+	undoError      error
+	recordedAmount string
+	amountError    error
+	exportedTo     string
+	exportError    error
+	edited         string
+	deletedHabit   string
+	noteError      error
 }
 
 func (m ListModel) Init() tea.Cmd {
@@ -73,9 +155,74 @@ func (m ListModel) Init() tea.Cmd {
 }
 
 func (m ListModel) updateHabitsList() ListModel {
-	habits := m.db.GetActiveHabits()
+	habits := m.db.GetActiveHabitsTagged(m.tag)
 	habitItems := itemsToList(habits)
+	// the filtered items would only be updated by a command, so start over
+	m.list.ResetFilter()
 	m.list.SetItems(habitItems)
+	m.delegate = habitDelegate(habits)
+	return m.updateSummaries()
+}
+
+// setMarked selects the habits in marked to be checked off together
+func (m ListModel) setMarked(marked map[string]bool) ListModel {
+	m.marked = marked
+	m.delegate.marked = marked
+	m.list.SetDelegate(m.delegate)
+	return m
+}
+
+// recordMarked checks off every marked habit at once
+func (m ListModel) recordMarked() ListModel {
+	var habits []string
+	for _, i := range m.list.Items() {
+		if h, ok := i.(habitItem); ok && m.marked[h.name] {
+			habits = append(habits, h.name)
+		}
+	}
+
+	recorded, err := m.db.RecordCompletions(habits)
+	if err != nil {
+		// nothing was recorded, so the selection is kept to try again
+		m.choice = strings.Join(habits, ", ")
+		m.StatusMessageFlags.recordError = err
+		return m
+	}
+	if len(recorded) == 0 {
+		m.StatusMessageFlags.nothingNew = true
+		return m.setMarked(nil)
+	}
+	today := m.db.Today().Time
+	for _, c := range recorded {
+		m.recorded = append(m.recorded, recordedCompletion{habit: c.Habit, date: today, amount: c.Amount})
+		m.StatusMessageFlags.recordedMany = append(m.StatusMessageFlags.recordedMany, c.Habit)
+	}
+	m.numRecorded += len(recorded)
+	return m.setMarked(nil).updateSummaries()
+}
+
+// forgetRecorded removes the completion of habit on date from the ones that
+// can be undone, once it was removed some other way
+func (m ListModel) forgetRecorded(habit string, date time.Time) ListModel {
+	var recorded []recordedCompletion
+	for _, r := range m.recorded {
+		if r.habit == habit && data.DateOf(r.date).Equal(data.DateOf(date)) {
+			m.numRecorded--
+			continue
+		}
+		recorded = append(recorded, r)
+	}
+	m.recorded = recorded
+	return m
+}
+
+// updateSummaries refreshes what is shown about how each habit has been going,
+// after completions were recorded or removed
+func (m ListModel) updateSummaries() ListModel {
+	if summaries, err := m.db.GetSummaries(m.tag); err == nil {
+		m.delegate.summaries = summaries
+	}
+	m.list.SetDelegate(m.delegate)
 	return m
 }
 
@@ -87,6 +234,10 @@ func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case tea.KeyMsg:
+		// while a filter is typed the keys are part of it, not commands
+		if m.list.FilterState() == list.Filtering && msg.String() != "ctrl+c" {
+			break
+		}
 		switch keypress := msg.String(); keypress {
 		case "ctrl+c":
 			m.StatusMessageFlags.quitting = true
@@ -98,22 +249,39 @@ func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "enter":
 			// reset message flags
 			m.StatusMessageFlags = StatusMessageFlags{}
-			i, ok := m.list.SelectedItem().(item)
+			if len(m.marked) > 0 {
+				return m.recordMarked(), nil
+			}
+			name, ok := selectedHabit(m.list)
 			if ok {
-				m.choice = string(i)
+				m.choice = name
 
-				completion, err := m.db.RecordCompletion(m.choice)
+				habit, err := m.db.GetHabit(m.choice)
+				if err == nil && habit.IsQuantitative() && !m.delegate.summaries[m.choice].DoneToday() {
+					// ask how much was done instead of just checking it off
+					amountInputModel := NewAmountInputModel(m, habit)
+					return amountInputModel.Update(nil)
+				}
+
+				completion, recorded, err := m.db.ToggleCompletion(m.choice)
 				if err != nil {
-					m.StatusMessageFlags.alreadyRecorded = true
+					m.StatusMessageFlags.recordError = err
 					return m, nil
 				}
-				if err == nil {
-					// make sure this flag is set to false so that
-					m.numRecorded++
-					m.StatusMessageFlags.newRecord = true
+				if !recorded {
+					m.StatusMessageFlags.unmarked = true
+					m.streak, _ = m.db.CurrentStreak(m.choice)
+					m = m.forgetRecorded(m.choice, completion.RecordedAt.Time)
+					return m.updateSummaries(), nil
 				}
+				m.numRecorded++
+				m.StatusMessageFlags.newRecord = true
+				m.recorded = append(m.recorded, recordedCompletion{habit: m.choice, date: m.db.Today().Time})
 
 				m.streak = completion.Streak
+				m = m.updateSummaries()
+				noteInputModel := NewNoteInputModel(m, m.choice, m.db.Today().Time)
+				return noteInputModel.Update(nil)
 			}
 			return m, nil
 
@@ -126,17 +294,34 @@ func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// And the text field starts with this letter already in there
 			return textInputModel.Update(nil)
 
-			// turn off esc exiting the program
+			// turn off esc exiting the program, it only clears the filter and
+			// the selection
 		case "esc":
-			return m, nil
+			m.list.ResetFilter()
+			return m.setMarked(nil), nil
+
+		case " ":
+			name, ok := selectedHabit(m.list)
+			if !ok {
+				return m, nil
+			}
+			marked := map[string]bool{}
+			for h := range m.marked {
+				marked[h] = true
+			}
+			if marked[name] {
+				delete(marked, name)
+			} else {
+				marked[name] = true
+			}
+			return m.setMarked(marked), nil
 
 		case "a":
 			// reset message flags
 			m.StatusMessageFlags = StatusMessageFlags{}
 
-			i, ok := m.list.SelectedItem().(item)
-			if ok {
-				m.choice = string(i)
+			if name, ok := selectedHabit(m.list); ok {
+				m.choice = name
 			}
 			err := m.db.ArchiveHabit(m.choice)
 			if err != nil {
@@ -146,12 +331,100 @@ func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m = m.updateHabitsList()
 			return m, nil
 
+		case "b":
+			m.StatusMessageFlags = StatusMessageFlags{}
+			name, ok := selectedHabit(m.list)
+			if !ok {
+				return m, nil
+			}
+			// go to the date picker to record a completion on an earlier day
+			backfillModel := NewBackfillModel(m, name)
+			return backfillModel.Update(nil)
+
+		case "u":
+			m.StatusMessageFlags = StatusMessageFlags{}
+			if len(m.recorded) == 0 {
+				m.StatusMessageFlags.nothingToUndo = true
+				return m, nil
+			}
+
+			last := m.recorded[len(m.recorded)-1]
+			m.choice = last.habit
+			var err error
+			if last.amount > 0 {
+				_, err = m.db.RecordAmountOn(last.habit, last.date, -last.amount)
+			} else {
+				err = m.db.DeleteCompletion(last.habit, last.date)
+			}
+			if err != nil {
+				m.StatusMessageFlags.undoError = err
+				return m, nil
+			}
+			m.recorded = m.recorded[:len(m.recorded)-1]
+			m.numRecorded--
+			m.StatusMessageFlags.undone = last.date.Format("2006-01-02")
+			m = m.updateSummaries()
+			return m, nil
+
+		case "x":
+			m.StatusMessageFlags = StatusMessageFlags{}
+			path := fmt.Sprintf("habits-export-%s.json", m.db.Today())
+			if err := m.exportToFile(path); err != nil {
+				m.StatusMessageFlags.exportError = err
+				return m, nil
+			}
+			m.StatusMessageFlags.exportedTo = path
+			return m, nil
+
 		case "r":
 			m.StatusMessageFlags = StatusMessageFlags{}
 			// go to restore habits page
 			// restoreHabitsModel.Update(nil)
 			archivedHabitsModel := NewArchivedHabitsModel(m)
 			return archivedHabitsModel.Update(nil)
+		case "e":
+			m.StatusMessageFlags = StatusMessageFlags{}
+			name, ok := selectedHabit(m.list)
+			if !ok {
+				return m, nil
+			}
+			habit, err := m.db.GetHabit(name)
+			if err != nil {
+				return m, nil
+			}
+			editHabitModel := NewEditHabitModel(m, habit)
+			return editHabitModel.Update(nil)
+		case "i":
+			m.StatusMessageFlags = StatusMessageFlags{}
+			name, ok := selectedHabit(m.list)
+			if !ok {
+				return m, nil
+			}
+			habit, err := m.db.GetHabit(name)
+			if err != nil {
+				return m, nil
+			}
+			historyModel := NewHistoryModel(m, habit)
+			return historyModel.Update(nil)
+		case "y":
+			m.StatusMessageFlags = StatusMessageFlags{}
+			// start out with the selected habit
+			var habit string
+			if name, ok := selectedHabit(m.list); ok {
+				habit = name
+			}
+			heatmapModel := NewHeatmapModel(m, habit)
+			return heatmapModel.Update(nil)
+		case "s":
+			m.StatusMessageFlags = StatusMessageFlags{}
+			statsModel := NewStatsModel(m)
+			return statsModel.Update(nil)
+		case "t":
+			m.StatusMessageFlags = StatusMessageFlags{}
+			m.tag = nextTag(m.db, m.tag)
+			m.list.Title = listTitle(m.tag)
+			m = m.updateHabitsList()
+			return m, nil
 		case "o":
 			// go to overview table page
 			selectYearModel := NewSelectYearModel(m)
@@ -165,6 +438,70 @@ func (m ListModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m = m.updateHabitsList()
 		return m, nil
 
+	case backfilledMsg:
+		m.StatusMessageFlags = StatusMessageFlags{}
+		m.choice = msg.choice
+		if msg.err != nil {
+			m.StatusMessageFlags.backfillError = msg.err
+			return m, nil
+		}
+		m.numRecorded++
+		m.streak = msg.streak
+		m.StatusMessageFlags.backfilledDate = msg.date
+		m = m.updateSummaries()
+		if date, err := time.Parse("2006-01-02", msg.date); err == nil {
+			m.recorded = append(m.recorded, recordedCompletion{habit: msg.choice, date: date})
+		}
+		return m, nil
+
+	case recordedAmountMsg:
+		m.StatusMessageFlags = StatusMessageFlags{}
+		m.choice = msg.choice
+		if msg.err != nil {
+			m.StatusMessageFlags.amountError = msg.err
+			return m, nil
+		}
+		m.numRecorded++
+		m.streak = msg.completion.Streak
+		m.recorded = append(m.recorded, recordedCompletion{habit: msg.choice, date: m.db.Today().Time, amount: msg.amount})
+		m.StatusMessageFlags.recordedAmount = data.FormatAmount(msg.completion.Amount, "")
+		m = m.updateSummaries()
+		return m, nil
+
+	case editedHabitMsg:
+		m.StatusMessageFlags = StatusMessageFlags{}
+		m.StatusMessageFlags.edited = msg.to
+		// entries of this session can still be undone under the new name
+		for i := range m.recorded {
+			if m.recorded[i].habit == msg.from {
+				m.recorded[i].habit = msg.to
+			}
+		}
+		m = m.updateHabitsList()
+		return m, nil
+
+	case savedNoteMsg:
+		// keep showing what was recorded unless the note got lost
+		if msg.err != nil {
+			m.StatusMessageFlags = StatusMessageFlags{}
+			m.choice = msg.choice
+			m.StatusMessageFlags.noteError = msg.err
+		}
+		return m, nil
+
+	case deletedHabitMsg:
+		m.StatusMessageFlags = StatusMessageFlags{}
+		m.StatusMessageFlags.deletedHabit = msg.choice
+		// its completions are gone, so there is nothing left to undo
+		var recorded []recordedCompletion
+		for _, r := range m.recorded {
+			if r.habit != msg.choice {
+				recorded = append(recorded, r)
+			}
+		}
+		m.recorded = recorded
+		return m, nil
+
 	case restoredHabitMsg:
 		m.StatusMessageFlags = StatusMessageFlags{}
 		m.StatusMessageFlags.restoredHabit = msg.choice
@@ -188,8 +525,24 @@ func (m ListModel) View() string {
 		s = notificationTextStyle.Render(fmt.Sprintf("Added %s as a new goal to track.", m.StatusMessageFlags.newEntry))
 	}
 
-	if m.StatusMessageFlags.alreadyRecorded {
-		s = notificationTextStyle.Render(fmt.Sprintf("Completion for goal '%s' already recorded for today.", m.choice))
+	if m.StatusMessageFlags.unmarked {
+		s = notificationTextStyle.Render(fmt.Sprintf("Unmarked %s for today. Current streak: %d", m.choice, m.streak))
+	}
+
+	if m.StatusMessageFlags.recordError != nil {
+		s = notificationTextStyle.Render(fmt.Sprintf(
+			"Could not record %s: %s", m.choice, m.StatusMessageFlags.recordError.Error(),
+		))
+	}
+
+	if m.StatusMessageFlags.recordedMany != nil {
+		s = notificationTextStyle.Render(fmt.Sprintf(
+			"Recorded %d goals: %s", len(m.StatusMessageFlags.recordedMany), strings.Join(m.StatusMessageFlags.recordedMany, ", "),
+		))
+	}
+
+	if m.StatusMessageFlags.nothingNew {
+		s = notificationTextStyle.Render("Nothing new to record, the selected goals were already done today.")
 	}
 
 	if m.StatusMessageFlags.archived {
@@ -199,52 +552,211 @@ func (m ListModel) View() string {
 				m.choice))
 	}
 
+	if m.StatusMessageFlags.edited != "" {
+		s = notificationTextStyle.Render(fmt.Sprintf("Saved %s", m.StatusMessageFlags.edited))
+	}
+
 	if m.StatusMessageFlags.restoredHabit != "" {
 		s = notificationTextStyle.Render(fmt.Sprintf(
 			"Restored %s", m.StatusMessageFlags.restoredHabit,
 		))
 	}
 
+	if m.StatusMessageFlags.noteError != nil {
+		s = notificationTextStyle.Render(fmt.Sprintf(
+			"Could not save the note on %s: %s", m.choice, m.StatusMessageFlags.noteError.Error(),
+		))
+	}
+
+	if m.StatusMessageFlags.deletedHabit != "" {
+		s = notificationTextStyle.Render(fmt.Sprintf(
+			"Deleted %s", m.StatusMessageFlags.deletedHabit,
+		))
+	}
+
+	if m.StatusMessageFlags.backfilledDate != "" {
+		s = notificationTextStyle.Render(fmt.Sprintf(
+			"Recorded %s on %s. Streak on that day: %d",
+			m.choice, m.StatusMessageFlags.backfilledDate, m.streak,
+		))
+	}
+
+	if m.StatusMessageFlags.backfillError != nil {
+		s = notificationTextStyle.Render(fmt.Sprintf(
+			"Could not record %s: %s", m.choice, m.StatusMessageFlags.backfillError.Error(),
+		))
+	}
+
+	if m.StatusMessageFlags.recordedAmount != "" {
+		s = notificationTextStyle.Render(fmt.Sprintf(
+			"Recorded %s. Total today: %s. Current streak: %d",
+			m.choice, m.StatusMessageFlags.recordedAmount, m.streak,
+		))
+	}
+
+	if m.StatusMessageFlags.amountError != nil {
+		s = notificationTextStyle.Render(fmt.Sprintf(
+			"Could not record %s: %s", m.choice, m.StatusMessageFlags.amountError.Error(),
+		))
+	}
+
+	if m.StatusMessageFlags.undone != "" {
+		s = notificationTextStyle.Render(fmt.Sprintf(
+			"Undid completion of %s on %s", m.choice, m.StatusMessageFlags.undone,
+		))
+	}
+
+	if m.StatusMessageFlags.nothingToUndo {
+		s = notificationTextStyle.Render("Nothing to undo this session.")
+	}
+
+	if m.StatusMessageFlags.undoError != nil {
+		s = notificationTextStyle.Render(fmt.Sprintf(
+			"Could not undo %s: %s", m.choice, m.StatusMessageFlags.undoError.Error(),
+		))
+	}
+
+	if m.StatusMessageFlags.exportedTo != "" {
+		s = notificationTextStyle.Render(fmt.Sprintf("Exported all habits to %s", m.StatusMessageFlags.exportedTo))
+	}
+
+	if m.StatusMessageFlags.exportError != nil {
+		s = notificationTextStyle.Render(fmt.Sprintf("Could not export: %s", m.StatusMessageFlags.exportError.Error()))
+	}
+
 	if m.StatusMessageFlags.quitting {
 		s = notificationTextStyle.Render(fmt.Sprintf("You recorded %d completed goals this session. Goodbye", m.numRecorded))
 		return s
 	}
 
-	return "\n" + s + "\n\n" + m.list.View() + m.helpView()
+	return "\n" + s + "\n\n" + filteredListView(m.list) + m.helpView()
 
 }
 
 func (m ListModel) helpView() string {
-	return helpStyle.Render("\n ↑/k: up • ↓/j: down • ctrl+c: quit • a: archive • n: create entry • o: overview \n")
+	return helpStyle.Render("\n ↑/k: up • ↓/j: down • /: search • enter: done/undo • space: select • ctrl+c: quit • a: archive • b: backfill • e: edit • u: undo • n: create entry • o: overview • i: history • s: stats • t: next tag • y: year • x: export \n")
+}
+
+func (m ListModel) exportToFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := m.db.WriteExport(f, data.FormatJSON); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func listTitle(tag string) string {
+	if tag == "" {
+		return "What goal did you complete today?"
+	}
+	return fmt.Sprintf("What %s goal did you complete today?", tag)
+}
+
+// nextTag returns the tag that comes after tag, or no tag after the last one,
+// so that pressing t goes through every tag and back to all habits
+func nextTag(db data.Database, tag string) string {
+	tags, err := db.GetTags()
+	if err != nil {
+		return ""
+	}
+	for i, t := range tags {
+		if t == tag {
+			if i+1 < len(tags) {
+				return tags[i+1]
+			}
+			return ""
+		}
+	}
+	if tag == "" && len(tags) > 0 {
+		return tags[0]
+	}
+	return ""
+}
+
+// habitDelegate shows habits in their colours
+func habitDelegate(habits []data.Habit) itemDelegate {
+	colors := map[string]string{}
+	var nameWidth int
+	for _, h := range habits {
+		if h.Color != "" {
+			colors[h.Name] = h.Color
+		}
+		if n := utf8.RuneCountInString(h.Name); n > nameWidth {
+			nameWidth = n
+		}
+	}
+	return itemDelegate{colors: colors, nameWidth: nameWidth}
+}
+
+// checkbox shows whether the habit was done today, or partly done towards its
+// target
+func checkbox(s data.Summary) string {
+	switch today := s.LastWeek[len(s.LastWeek)-1]; {
+	case today >= 1:
+		return "[x]"
+	case today > 0:
+		return "[~]"
+	}
+	return "[ ]"
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws a bar per day, higher the more was done, and a dot for days
+// nothing was done
+func sparkline(days [7]float64) string {
+	var b strings.Builder
+	for _, done := range days {
+		if done <= 0 {
+			b.WriteRune('·')
+			continue
+		}
+		i := int(math.Ceil(done*float64(len(sparks)))) - 1
+		if i >= len(sparks) {
+			i = len(sparks) - 1
+		}
+		b.WriteRune(sparks[i])
+	}
+	return b.String()
+}
+
+// filteredListView shows l with the filter in the title once it is applied,
+// as the status bar that would show it is hidden
+func filteredListView(l list.Model) string {
+	if l.FilterState() == list.FilterApplied {
+		l.Title += fmt.Sprintf(" (matching %q, esc: clear)", l.FilterValue())
+	}
+	return l.View()
 }
 
 func itemsToList(habits []data.Habit) []list.Item {
 	items := make([]list.Item, len(habits))
 
 	for i, h := range habits {
-		items[i] = list.Item(item(h.Name))
+		items[i] = list.Item(habitItem{name: h.Name, description: h.Description, tags: h.TagNames()})
 	}
 	return items
 }
 
-func NewList(db *gorm.DB) ListModel {
-	hdb := data.Database{DB: db}
-
+func NewList(hdb data.Database) ListModel {
 	habits := hdb.GetActiveHabits()
 
 	items := itemsToList(habits)
 
 	const defaultWidth = 200
 
-	l := list.New(items, itemDelegate{}, defaultWidth, listHeight)
-	l.Title = "What goal did you complete today?"
+	l := list.New(items, habitDelegate(habits), defaultWidth, listHeight)
+	l.Title = listTitle("")
 	l.SetShowStatusBar(false)
-	l.SetFilteringEnabled(false)
 	l.Styles.Title = titleStyle
 	l.Styles.PaginationStyle = paginationStyle
 	l.Styles.HelpStyle = helpStyle
 	l.SetShowHelp(false)
 
-	m := ListModel{list: l, db: hdb}
-	return m
+	m := ListModel{list: l, db: hdb, delegate: habitDelegate(habits)}
+	return m.updateSummaries()
 }
@@ -0,0 +1,183 @@
+package pages
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bodowd/habits/data"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// heatmapColors go from no activity to the most activity of the year
+var heatmapColors = []lipgloss.Color{"238", "22", "28", "34", "46"}
+
+var heatmapWeekdays = []string{"Mon", "", "Wed", "", "Fri", "", "Sun"}
+
+type HeatmapModel struct {
+	listModel ListModel
+	// habits to pick from, the empty name stands for all habits combined
+	habits   []string
+	habitIdx int
+	years    []int
+	yearIdx  int
+	activity map[data.Date]float64
+	err      error
+}
+
+func NewHeatmapModel(listModel ListModel, habit string) HeatmapModel {
+	m := HeatmapModel{listModel: listModel, habits: []string{""}}
+	for _, h := range listModel.db.GetAllHabits() {
+		m.habits = append(m.habits, h.Name)
+		if h.Name == habit {
+			m.habitIdx = len(m.habits) - 1
+		}
+	}
+
+	// always offer the current year, even before anything was recorded in it
+	thisYear := listModel.db.Today().Year()
+	seen := map[int]bool{thisYear: true}
+	m.years = []int{thisYear}
+	for _, y := range listModel.db.GetAvailableYears() {
+		year, err := strconv.Atoi(y)
+		if err == nil && !seen[year] {
+			seen[year] = true
+			m.years = append(m.years, year)
+		}
+	}
+	sort.Ints(m.years)
+	for i, y := range m.years {
+		if y == thisYear {
+			m.yearIdx = i
+		}
+	}
+
+	return m.load()
+}
+
+func (m HeatmapModel) load() HeatmapModel {
+	m.activity, m.err = m.listModel.db.GetYearActivity(m.habits[m.habitIdx], m.years[m.yearIdx])
+	return m
+}
+
+func (m HeatmapModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m HeatmapModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "ctrl+o":
+			return m.listModel.Update(nil)
+		case "left", "h":
+			if m.yearIdx > 0 {
+				m.yearIdx--
+				return m.load(), nil
+			}
+		case "right", "l":
+			if m.yearIdx < len(m.years)-1 {
+				m.yearIdx++
+				return m.load(), nil
+			}
+		case "tab":
+			m.habitIdx = (m.habitIdx + 1) % len(m.habits)
+			return m.load(), nil
+		case "shift+tab":
+			m.habitIdx = (m.habitIdx + len(m.habits) - 1) % len(m.habits)
+			return m.load(), nil
+		}
+	}
+	return m, nil
+}
+
+func (m HeatmapModel) View() string {
+	name := m.habits[m.habitIdx]
+	if name == "" {
+		name = "All habits"
+	}
+	year := m.years[m.yearIdx]
+
+	var s string
+	if m.err != nil {
+		s = notificationTextStyle.Render(fmt.Sprintf("Could not load %s: %s", name, m.err.Error()))
+	} else {
+		s = notificationTextStyle.Render(fmt.Sprintf("%s in %d: active on %d days", name, year, len(m.activity)))
+	}
+	return s + "\n" + renderHeatmap(year, m.activity) + m.helpView()
+}
+
+// renderHeatmap draws year with a column per week, starting on Monday, and a
+// row per weekday. Cells are coloured relative to the busiest day.
+func renderHeatmap(year int, activity map[data.Date]float64) string {
+	most := 0.0
+	for _, v := range activity {
+		if v > most {
+			most = v
+		}
+	}
+
+	first := data.NewDate(year, time.January, 1)
+	last := data.NewDate(year, time.December, 31)
+	offset := (int(first.Weekday()) + 6) % 7
+	start := first.AddDays(-offset)
+
+	var weeks []data.Date
+	for w := start; !w.After(last); w = w.AddDays(7) {
+		weeks = append(weeks, w)
+	}
+
+	// month names above the week their first day falls in
+	months := []byte(strings.Repeat(" ", 2*len(weeks)+3))
+	for i, w := range weeks {
+		for d := 0; d < 7; d++ {
+			day := w.AddDays(d)
+			if day.Day() == 1 && day.Year() == year {
+				copy(months[2*i:], day.Month().String()[:3])
+			}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("      " + strings.TrimRight(string(months), " ") + "\n")
+	for d := 0; d < 7; d++ {
+		b.WriteString(fmt.Sprintf("  %-4s", heatmapWeekdays[d]))
+		for _, w := range weeks {
+			day := w.AddDays(d)
+			if day.Year() != year {
+				b.WriteString("  ")
+				continue
+			}
+			b.WriteString(heatmapCell(activity[day], most) + " ")
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n      Less ")
+	for level := range heatmapColors {
+		b.WriteString(lipgloss.NewStyle().Foreground(heatmapColors[level]).Render("■") + " ")
+	}
+	b.WriteString("More\n")
+	return b.String()
+}
+
+func heatmapCell(value, most float64) string {
+	level := 0
+	if value > 0 && most > 0 {
+		steps := len(heatmapColors) - 1
+		level = 1 + int(value/most*float64(steps)-0.001)
+		if level > steps {
+			level = steps
+		}
+	}
+	return lipgloss.NewStyle().Foreground(heatmapColors[level]).Render("■")
+}
+
+func (m HeatmapModel) helpView() string {
+	return helpStyle.Render("\n ←/h: previous year • →/l: next year • tab: next habit • ctrl+c: quit • ctrl+o: back \n")
+}
@@ -0,0 +1,86 @@
+package pages
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/bodowd/habits/data"
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// HistoryModel lists every completion of a habit with its note, most recent
+// first
+type HistoryModel struct {
+	table     table.Model
+	habit     data.Habit
+	listModel ListModel
+	err       error
+}
+
+func NewHistoryModel(listModel ListModel, habit data.Habit) HistoryModel {
+	columns := []table.Column{
+		{Title: "Date", Width: 12},
+		{Title: "Streak", Width: 8},
+	}
+	if habit.IsQuantitative() {
+		columns = append(columns, table.Column{Title: "Amount", Width: 12})
+	}
+	columns = append(columns, table.Column{Title: "Note", Width: 50})
+
+	completions, err := listModel.db.GetHistory(habit.Name)
+
+	var rows []table.Row
+	for _, c := range completions {
+		row := table.Row{c.RecordedAt.String(), strconv.Itoa(c.Streak)}
+		if habit.IsQuantitative() {
+			row = append(row, data.FormatAmount(c.Amount, habit.Unit))
+		}
+		rows = append(rows, append(row, c.Note))
+	}
+
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(listHeight))
+	t.SetStyles(table.DefaultStyles())
+
+	return HistoryModel{table: t, habit: habit, listModel: listModel, err: err}
+}
+
+func (m HistoryModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m HistoryModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return m, tea.Quit
+		case tea.KeyCtrlO:
+			return m.listModel.Update(nil)
+		}
+	}
+
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m HistoryModel) View() string {
+	s := titleStyle.Render(m.habit.Name) + "\n"
+	if m.habit.Description != "" {
+		s += titleStyle.Render(m.habit.Description) + "\n"
+	}
+	s += "\n"
+	if m.err != nil {
+		s += notificationTextStyle.Render(fmt.Sprintf("Could not load the history: %s", m.err.Error())) + "\n"
+	}
+	return s + m.table.View() + m.helpView()
+}
+
+func (m HistoryModel) helpView() string {
+	return helpStyle.Render("\n ↑/k: up • ↓/j: down • ctrl+c: quit • ctrl+o: back \n")
+}
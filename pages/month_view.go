@@ -0,0 +1,229 @@
+package pages
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bodowd/habits/data"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const monthCellWidth = 9
+
+var (
+	todayStyle     = lipgloss.NewStyle().Reverse(true)
+	weekNumStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+	untrackedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// MonthModel shows a month as a calendar with a row per week. Each day shows
+// how many habits were done, or whether the focused habit was done.
+type MonthModel struct {
+	listModel ListModel
+	year      int
+	month     time.Month
+	// habits tracked at some point during the month
+	habits       []data.Habit
+	showArchived bool
+	// tag limits the habits to those tagged with it, empty for all
+	tag string
+	// focus is 0 for all habits, otherwise habits[focus-1]
+	focus       int
+	completions []data.HabitAndCompletion
+}
+
+func NewMonthModel(listModel ListModel, year int, month time.Month) MonthModel {
+	m := MonthModel{listModel: listModel, year: year, month: month, tag: listModel.tag}
+	return m.load()
+}
+
+func (m MonthModel) load() MonthModel {
+	var focused string
+	if m.focus > 0 {
+		focused = m.habits[m.focus-1].Name
+	}
+
+	first := data.NewDate(m.year, m.month, 1)
+	last := first.AddDays(first.AddDate(0, 1, -1).Day() - 1)
+	m.habits = m.listModel.db.GetHabitsActiveBetween(first, last, m.showArchived, m.tag)
+	m.completions = m.listModel.db.GetHabitsAndCompletions(int(m.month), m.year, m.showArchived, m.tag)
+
+	// keep the focus on the same habit if it is still shown
+	m.focus = 0
+	for i, h := range m.habits {
+		if h.Name == focused {
+			m.focus = i + 1
+		}
+	}
+	return m
+}
+
+func (m MonthModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m MonthModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "ctrl+c":
+			return m, tea.Quit
+		case "ctrl+o":
+			return m.listModel.Update(nil)
+		case "left", "h":
+			return m.addMonths(-1), nil
+		case "right", "l":
+			return m.addMonths(1), nil
+		case "a":
+			m.showArchived = !m.showArchived
+			return m.load(), nil
+		case "t":
+			m.tag = nextTag(m.listModel.db, m.tag)
+			return m.load(), nil
+		case "tab":
+			m.focus = (m.focus + 1) % (len(m.habits) + 1)
+			return m, nil
+		case "shift+tab":
+			m.focus = (m.focus + len(m.habits)) % (len(m.habits) + 1)
+			return m, nil
+		}
+	}
+	return m, nil
+}
+
+func (m MonthModel) addMonths(n int) MonthModel {
+	first := time.Date(m.year, m.month+time.Month(n), 1, 0, 0, 0, 0, time.UTC)
+	m.year, m.month = first.Year(), first.Month()
+	return m.load()
+}
+
+// marks returns what to show on each day of the month, keyed by day, and
+// which days none of the shown habits were tracked on
+func (m MonthModel) marks() (map[int]string, map[int]bool) {
+	marks := map[int]string{}
+	untracked := map[int]bool{}
+	first := data.NewDate(m.year, m.month, 1)
+
+	if m.focus == 0 {
+		done := map[int]map[string]bool{}
+		for _, c := range m.completions {
+			if !c.Habit.IsQuantitative() || c.Completion.Amount >= c.Habit.Target {
+				day := c.Completion.RecordedAt.Day()
+				if done[day] == nil {
+					done[day] = map[string]bool{}
+				}
+				done[day][c.Habit.Name] = true
+			}
+		}
+		for day := first; day.Month() == m.month; day = day.AddDays(1) {
+			// habits done before they were created still count
+			tracked := 0
+			for _, h := range m.habits {
+				if h.TrackedOn(day) || done[day.Day()][h.Name] {
+					tracked++
+				}
+			}
+			n := len(done[day.Day()])
+			switch {
+			case tracked == 0 && n == 0:
+				untracked[day.Day()] = true
+			case n > 0 || !day.After(m.listModel.db.Today()):
+				marks[day.Day()] = fmt.Sprintf("%d/%d", n, tracked)
+			}
+		}
+		return marks, untracked
+	}
+
+	habit := m.habits[m.focus-1]
+	for day := first; day.Month() == m.month; day = day.AddDays(1) {
+		if habit.TrackedOn(day) {
+			continue
+		}
+		untracked[day.Day()] = true
+		if day.Before(habit.CreatedOn) {
+			marks[day.Day()] = "-"
+		} else {
+			marks[day.Day()] = "pause"
+		}
+	}
+	for _, c := range m.completions {
+		if c.Habit.Name != habit.Name {
+			continue
+		}
+		mark := "x"
+		if habit.IsQuantitative() {
+			mark = data.FormatAmount(c.Completion.Amount, "")
+		}
+		marks[c.Completion.RecordedAt.Day()] = mark
+		delete(untracked, c.Completion.RecordedAt.Day())
+	}
+	return marks, untracked
+}
+
+func (m MonthModel) View() string {
+	focus := "all habits"
+	if m.tag != "" {
+		focus = fmt.Sprintf("all %s habits", m.tag)
+	}
+	if m.focus > 0 {
+		focus = m.habits[m.focus-1].Name
+		if !m.habits[m.focus-1].Active {
+			focus += " (archived)"
+		}
+	}
+	if m.showArchived {
+		focus += ", archived habits shown"
+	}
+	s := notificationTextStyle.Render(fmt.Sprintf("%s %d: %s", m.month, m.year, focus))
+	return s + "\n" + m.renderGrid() + m.helpView()
+}
+
+// renderGrid draws the month with weeks starting on Monday, each row led by
+// its ISO week number
+func (m MonthModel) renderGrid() string {
+	marks, untracked := m.marks()
+	today := m.listModel.db.Today()
+
+	var b strings.Builder
+	b.WriteString("  " + weekNumStyle.Render("Wk") + " ")
+	for _, d := range []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"} {
+		b.WriteString(fmt.Sprintf("%-*s", monthCellWidth, d))
+	}
+	b.WriteString("\n")
+
+	first := data.NewDate(m.year, m.month, 1)
+	offset := (int(first.Weekday()) + 6) % 7
+	for week := first.AddDays(-offset); week.Month() == m.month || week.Before(first); week = week.AddDays(7) {
+		_, weekNum := week.AddDays(3).ISOWeek()
+		b.WriteString("  " + weekNumStyle.Render(fmt.Sprintf("%2d", weekNum)) + " ")
+		for d := 0; d < 7; d++ {
+			day := week.AddDays(d)
+			if day.Month() != m.month {
+				b.WriteString(strings.Repeat(" ", monthCellWidth))
+				continue
+			}
+			cell := fmt.Sprintf("%2d %-*s", day.Day(), monthCellWidth-4, truncate(marks[day.Day()], monthCellWidth-4))
+			if day.Equal(today) {
+				cell = todayStyle.Render(cell)
+			} else if untracked[day.Day()] {
+				cell = untrackedStyle.Render(cell)
+			}
+			b.WriteString(cell + " ")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func truncate(s string, width int) string {
+	if len(s) > width {
+		return s[:width]
+	}
+	return s
+}
+
+func (m MonthModel) helpView() string {
+	return helpStyle.Render("\n ←/h: previous month • →/l: next month • tab: next habit • a: toggle archived • t: next tag • ctrl+c: quit • ctrl+o: back \n")
+}
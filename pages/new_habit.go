@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/bodowd/habits/data"
+	"github.com/charmbracelet/bubbles/list"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"gorm.io/gorm"
@@ -13,12 +15,27 @@ type (
 	errMsg error
 )
 
+// the steps of creating a new habit
+const (
+	nameStep = iota
+	scheduleStep
+	scheduleArgStep
+	targetStep
+	tagsStep
+)
+
 type TextInputModel struct {
-	textInput textinput.Model
-	text      string
-	err       error
-	listModel ListModel
-	db        *gorm.DB
+	textInput    textinput.Model
+	scheduleList list.Model
+	step         int
+	text         string
+	scheduleKind data.ScheduleKind
+	schedule     data.Schedule
+	target       float64
+	unit         string
+	err          error
+	listModel    ListModel
+	db           *gorm.DB
 }
 
 func NewTextInputModel(listModel ListModel) TextInputModel {
@@ -28,10 +45,25 @@ func NewTextInputModel(listModel ListModel) TextInputModel {
 	ti.CharLimit = 156
 	ti.Width = 20
 
+	kinds := make([]list.Item, len(data.ScheduleKinds))
+	for i, k := range data.ScheduleKinds {
+		kinds[i] = list.Item(item(k))
+	}
+
+	sl := list.New(kinds, itemDelegate{}, defaultWidth, listHeight)
+	sl.Title = "How often do you want to do it?"
+	sl.SetShowStatusBar(false)
+	sl.SetFilteringEnabled(false)
+	sl.Styles.Title = titleStyle
+	sl.Styles.PaginationStyle = paginationStyle
+	sl.Styles.HelpStyle = helpStyle
+	sl.SetShowHelp(false)
+
 	return TextInputModel{
-		textInput: ti,
-		err:       nil,
-		listModel: listModel,
+		textInput:    ti,
+		scheduleList: sl,
+		err:          nil,
+		listModel:    listModel,
 	}
 }
 
@@ -49,10 +81,22 @@ func (m TextInputModel) Init() tea.Cmd {
 	return textinput.Blink
 }
 
+// scheduleArgPlaceholders explains what to type for each schedule kind
+// that needs an argument
+var scheduleArgPlaceholders = map[data.ScheduleKind]string{
+	data.ScheduleWeekdays: "mon,wed,fri",
+	data.ScheduleWeekly:   "times per week",
+	data.ScheduleMonthly:  "times per month",
+	data.ScheduleInterval: "number of days",
+}
+
 func (m TextInputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 
 	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.scheduleList.SetWidth(msg.Width)
+		return m, nil
 	case tea.KeyMsg:
 		switch msg.Type {
 		case tea.KeyCtrlC:
@@ -60,20 +104,59 @@ func (m TextInputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyCtrlO:
 			return m.listModel.Update(msg)
 		case tea.KeyEnter:
-			m.text = m.textInput.Value()
-			_, err := m.listModel.db.CreateHabit(m.text)
-			if err != nil {
-				m.text = ""
-				if strings.Contains(err.Error(), "UNIQUE") {
-					m.err = &DuplicateError{}
+			switch m.step {
+			case nameStep:
+				m.text = strings.TrimSpace(m.textInput.Value())
+				if m.text == "" {
+					return m, nil
 				}
+				m.err = nil
+				m.step = scheduleStep
 				return m, nil
+
+			case scheduleStep:
+				i, ok := m.scheduleList.SelectedItem().(item)
+				if !ok {
+					return m, nil
+				}
+				m.scheduleKind = data.ScheduleKind(i)
+				if m.scheduleKind == data.ScheduleDaily {
+					m.schedule = data.DailySchedule()
+					return m.toTargetStep(), nil
+				}
+				m.step = scheduleArgStep
+				m.textInput.Reset()
+				m.textInput.Placeholder = scheduleArgPlaceholders[m.scheduleKind]
+				return m, nil
+
+			case scheduleArgStep:
+				schedule, err := data.ParseSchedule(m.scheduleKind, m.textInput.Value())
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.err = nil
+				m.schedule = schedule
+				return m.toTargetStep(), nil
+
+			case targetStep:
+				target, unit, err := data.ParseTarget(m.textInput.Value())
+				if err != nil {
+					m.err = err
+					return m, nil
+				}
+				m.err = nil
+				m.target, m.unit = target, unit
+				m.step = tagsStep
+				m.textInput.Reset()
+				m.textInput.Placeholder = "e.g. health, work"
+				// habits created while looking at a tag likely belong to it
+				m.textInput.SetValue(m.listModel.tag)
+				return m, nil
+
+			case tagsStep:
+				return m.save(data.ParseTags(m.textInput.Value()))
 			}
-			saved := userSavedMsg{
-				text: m.text,
-			}
-			// switch view back to list
-			return m.listModel.Update(saved)
 		}
 
 		// handle errors just like any other message
@@ -82,24 +165,78 @@ func (m TextInputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
+	if m.step == scheduleStep {
+		m.scheduleList, cmd = m.scheduleList.Update(msg)
+		return m, cmd
+	}
+
 	m.textInput, cmd = m.textInput.Update(msg)
 
 	return m, cmd
 }
 
+func (m TextInputModel) toTargetStep() TextInputModel {
+	m.step = targetStep
+	m.textInput.Reset()
+	m.textInput.Placeholder = "e.g. 8 glasses"
+	return m
+}
+
+func (m TextInputModel) save(tags []string) (tea.Model, tea.Cmd) {
+	_, err := m.listModel.db.CreateTaggedHabit(data.Habit{
+		Name:     m.text,
+		Schedule: m.schedule,
+		Target:   m.target,
+		Unit:     m.unit,
+	}, tags)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			m.err = &DuplicateError{}
+		} else {
+			m.err = err
+		}
+		// start over with the name
+		m.text = ""
+		m.step = nameStep
+		m.textInput.Reset()
+		m.textInput.Placeholder = "Enter habit"
+		return m, nil
+	}
+	saved := userSavedMsg{
+		text: m.text,
+	}
+	// switch view back to list
+	return m.listModel.Update(saved)
+}
+
 func (m TextInputModel) View() string {
 	var s string = ""
-	s = "What new goal do you want to track?\n\n"
+
+	switch m.step {
+	case scheduleStep:
+		s = m.scheduleList.View()
+		s += helpStyle.Render("\n ↑/k: up • ↓/j: down • ctrl+c: quit • ctrl+o: back • enter: select\n")
+		return s
+
+	case scheduleArgStep:
+		s = fmt.Sprintf("How often do you want to do %s (%s)?\n\n", m.text, m.scheduleKind)
+
+	case targetStep:
+		s = fmt.Sprintf("How much of %s do you want to do per day? Leave empty to just check it off.\n\n", m.text)
+
+	case tagsStep:
+		s = fmt.Sprintf("Which tags does %s have? Separate them with commas, or leave empty for none.\n\n", m.text)
+
+	default:
+		s = "What new goal do you want to track?\n\n"
+	}
+
 	s += fmt.Sprintf(
 		"%s\n\n%s",
 		m.textInput.View(),
 		helpStyle.Render("\n ctrl+c: quit • ctrl+o: back • enter: save entry\n"),
 	) + "\n"
 
-	if m.text != "" {
-		s += fmt.Sprintf("Saved %s!", m.text)
-	}
-
 	if m.err != nil {
 		s += fmt.Sprintf("Error: %s", m.err.Error())
 	}
@@ -0,0 +1,77 @@
+package pages
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// NoteInputModel asks for an optional note on a completion that was just
+// recorded
+type NoteInputModel struct {
+	textInput textinput.Model
+	habit     string
+	date      time.Time
+	listModel ListModel
+}
+
+func NewNoteInputModel(listModel ListModel, habit string, date time.Time) NoteInputModel {
+	ti := textinput.New()
+	ti.Placeholder = "how did it go?"
+	ti.Focus()
+	ti.CharLimit = 280
+	ti.Width = 40
+
+	return NoteInputModel{
+		textInput: ti,
+		habit:     habit,
+		date:      date,
+		listModel: listModel,
+	}
+}
+
+type savedNoteMsg struct {
+	choice string
+	err    error
+}
+
+func (m NoteInputModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m NoteInputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return m, tea.Quit
+		case tea.KeyCtrlO, tea.KeyEsc:
+			// the completion is recorded already, it just has no note
+			return m.listModel.Update(nil)
+		case tea.KeyEnter:
+			note := strings.TrimSpace(m.textInput.Value())
+			if note == "" {
+				return m.listModel.Update(nil)
+			}
+			_, err := m.listModel.db.SetCompletionNote(m.habit, m.date, note)
+			return m.listModel.Update(savedNoteMsg{choice: m.habit, err: err})
+		}
+	}
+
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+func (m NoteInputModel) View() string {
+	return fmt.Sprintf(
+		"Recorded %s. Anything to note about it? Leave empty to skip.\n\n%s\n\n%s\n",
+		m.habit,
+		m.textInput.View(),
+		helpStyle.Render("\n ctrl+c: quit • esc: skip • enter: save note\n"),
+	)
+}
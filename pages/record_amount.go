@@ -0,0 +1,96 @@
+package pages
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bodowd/habits/data"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type AmountInputModel struct {
+	textInput textinput.Model
+	habit     data.Habit
+	err       error
+	listModel ListModel
+}
+
+func NewAmountInputModel(listModel ListModel, habit data.Habit) AmountInputModel {
+	ti := textinput.New()
+	ti.Placeholder = habit.Unit
+	ti.Focus()
+	ti.CharLimit = 20
+	ti.Width = 20
+
+	return AmountInputModel{
+		textInput: ti,
+		habit:     habit,
+		listModel: listModel,
+	}
+}
+
+type recordedAmountMsg struct {
+	choice     string
+	amount     float64
+	completion data.Completion
+	err        error
+}
+
+func (m AmountInputModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m AmountInputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return m, tea.Quit
+		case tea.KeyCtrlO:
+			return m.listModel.Update(nil)
+		case tea.KeyEnter:
+			amount, err := strconv.ParseFloat(strings.TrimSpace(m.textInput.Value()), 64)
+			if err != nil || amount <= 0 {
+				m.err = fmt.Errorf("%q is not a positive number", m.textInput.Value())
+				return m, nil
+			}
+
+			completion, err := m.listModel.db.RecordAmount(m.habit.Name, amount)
+			recorded := recordedAmountMsg{
+				choice:     m.habit.Name,
+				amount:     amount,
+				completion: completion,
+				err:        err,
+			}
+			if err != nil {
+				return m.listModel.Update(recorded)
+			}
+			listModel, _ := m.listModel.Update(recorded)
+			noteInputModel := NewNoteInputModel(listModel.(ListModel), m.habit.Name, completion.RecordedAt.Time)
+			return noteInputModel.Update(nil)
+		}
+	}
+
+	m.textInput, cmd = m.textInput.Update(msg)
+	return m, cmd
+}
+
+func (m AmountInputModel) View() string {
+	s := fmt.Sprintf(
+		"How much %s did you do? (target: %s)\n\n%s\n\n%s\n",
+		m.habit.Name,
+		data.FormatAmount(m.habit.Target, m.habit.Unit),
+		m.textInput.View(),
+		helpStyle.Render("\n ctrl+c: quit • ctrl+o: back • enter: record\n"),
+	)
+
+	if m.err != nil {
+		s += fmt.Sprintf("Error: %s", m.err.Error())
+	}
+
+	return s
+}
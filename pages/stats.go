@@ -0,0 +1,106 @@
+package pages
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type StatsModel struct {
+	table     table.Model
+	listModel ListModel
+	// tag limits the stats to the habits tagged with it, empty for all
+	tag string
+	err error
+}
+
+func NewStatsModel(listModel ListModel) StatsModel {
+	columns := []table.Column{
+		{Title: "Habit", Width: 20},
+		{Title: "Current", Width: 8},
+		{Title: "Longest", Width: 8},
+		{Title: "Total", Width: 8},
+		{Title: "7 days", Width: 8},
+		{Title: "30 days", Width: 8},
+		{Title: "365 days", Width: 8},
+		{Title: "All time", Width: 8},
+	}
+
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithFocused(true),
+		table.WithHeight(listHeight))
+	t.SetStyles(table.DefaultStyles())
+
+	m := StatsModel{table: t, listModel: listModel, tag: listModel.tag}
+	return m.load()
+}
+
+func (m StatsModel) load() StatsModel {
+	stats, err := m.listModel.db.GetActiveStatsTagged(m.tag)
+
+	var rows []table.Row
+	for _, s := range stats {
+		rows = append(rows, table.Row{
+			s.Habit,
+			strconv.Itoa(s.CurrentStreak),
+			strconv.Itoa(s.LongestStreak),
+			strconv.Itoa(s.Total),
+			percent(s.Rate7),
+			percent(s.Rate30),
+			percent(s.Rate365),
+			percent(s.RateSinceStart),
+		})
+	}
+
+	m.table.SetRows(rows)
+	m.table.SetCursor(0)
+	m.err = err
+	return m
+}
+
+func percent(rate float64) string {
+	return fmt.Sprintf("%.0f%%", rate*100)
+}
+
+func (m StatsModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m StatsModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC:
+			return m, tea.Quit
+		case tea.KeyCtrlO:
+			return m.listModel.Update(nil)
+		case tea.KeyRunes:
+			if msg.String() == "t" {
+				m.tag = nextTag(m.listModel.db, m.tag)
+				return m.load(), nil
+			}
+		}
+	}
+
+	m.table, cmd = m.table.Update(msg)
+	return m, cmd
+}
+
+func (m StatsModel) View() string {
+	var s string
+	if m.tag != "" {
+		s = notificationTextStyle.Render(fmt.Sprintf("Habits tagged %s", m.tag)) + "\n"
+	}
+	if m.err != nil {
+		s += notificationTextStyle.Render(fmt.Sprintf("Could not load stats: %s", m.err.Error())) + "\n"
+	}
+	return s + m.table.View() + m.helpView()
+}
+
+func (m StatsModel) helpView() string {
+	return helpStyle.Render("\n ↑/k: up • ↓/j: down • t: next tag • ctrl+c: quit • ctrl+o: back \n")
+}
@@ -0,0 +1,148 @@
+// Package remind notifies about habits that are due but not done yet once
+// their reminder time has come.
+package remind
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"time"
+
+	"github.com/bodowd/habits/data"
+)
+
+// Notifier shows a notification to the user
+type Notifier interface {
+	Notify(title, message string) error
+}
+
+// NotifySend shows desktop notifications with notify-send
+type NotifySend struct{}
+
+func (NotifySend) Notify(title, message string) error {
+	return exec.Command("notify-send", "--app-name=habits", title, message).Run()
+}
+
+// Bell rings the terminal bell and writes the notification to Out
+type Bell struct {
+	Out io.Writer
+}
+
+func (b Bell) Notify(title, message string) error {
+	_, err := fmt.Fprintf(b.Out, "\a%s: %s\n", title, message)
+	return err
+}
+
+// Script runs the program at Path with the title and the message as its
+// arguments
+type Script struct {
+	Path string
+}
+
+func (s Script) Notify(title, message string) error {
+	out, err := exec.Command(s.Path, title, message).CombinedOutput()
+	if err != nil && len(out) > 0 {
+		return fmt.Errorf("%s: %w: %s", s.Path, err, out)
+	}
+	return err
+}
+
+// Reminder checks the database for reminders that came due and passes them
+// on to its notifier
+type Reminder struct {
+	db       data.Database
+	notifier Notifier
+	// last is when the reminders were last checked, zero before the first
+	// check
+	last time.Time
+	// failed holds the IDs of the habits whose notification failed, to be
+	// tried again on the next check
+	failed map[uint]bool
+}
+
+func NewReminder(db data.Database, notifier Notifier) *Reminder {
+	return &Reminder{db: db, notifier: notifier}
+}
+
+// Check notifies about the habits whose reminder went off since the last
+// check, or earlier today on the first check, and which still need doing
+// today. It returns the names of the habits it notified about. A failed
+// notification doesn't keep the others from being sent and is tried again on
+// the next check as long as the habit still needs doing today.
+func (r *Reminder) Check() ([]string, error) {
+	now := r.db.Now()
+	habits, err := r.db.DueReminders(r.last, now)
+	if err != nil {
+		return nil, err
+	}
+	if len(r.failed) > 0 {
+		habits, err = r.withFailed(habits, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+	r.last = now
+	r.failed = nil
+
+	var notified []string
+	var firstErr error
+	for _, h := range habits {
+		if err := r.notifier.Notify(h.Name, message(h)); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", h.Name, err)
+			}
+			if r.failed == nil {
+				r.failed = map[uint]bool{}
+			}
+			r.failed[h.ID] = true
+			continue
+		}
+		notified = append(notified, h.Name)
+	}
+	return notified, firstErr
+}
+
+// withFailed adds the habits whose notification failed on the last check to
+// habits, unless they were done since or their reminder was from another day
+func (r *Reminder) withFailed(habits []data.Habit, now time.Time) ([]data.Habit, error) {
+	today, err := r.db.DueReminders(time.Time{}, now)
+	if err != nil {
+		return nil, err
+	}
+	due := map[uint]bool{}
+	for _, h := range habits {
+		due[h.ID] = true
+	}
+	for _, h := range today {
+		if r.failed[h.ID] && !due[h.ID] {
+			habits = append(habits, h)
+		}
+	}
+	return habits, nil
+}
+
+// Run checks the reminders right away and then every interval until ctx is
+// done. Errors are written to errs and don't stop it.
+func (r *Reminder) Run(ctx context.Context, interval time.Duration, errs io.Writer) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.Check(); err != nil {
+			fmt.Fprintf(errs, "habits remind: %s\n", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func message(h data.Habit) string {
+	if h.Description != "" {
+		return fmt.Sprintf("Not done yet today: %s", h.Description)
+	}
+	return "Not done yet today"
+}
@@ -0,0 +1,221 @@
+package remind
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bodowd/habits/data"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+type notification struct {
+	title   string
+	message string
+}
+
+type fakeNotifier struct {
+	sent []notification
+	err  error
+	// failures is how many notifications fail before the next ones are sent
+	failures int
+}
+
+func (f *fakeNotifier) Notify(title, message string) error {
+	if f.err != nil {
+		return f.err
+	}
+	if f.failures > 0 {
+		f.failures--
+		return errors.New("no display")
+	}
+	f.sent = append(f.sent, notification{title, message})
+	return nil
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 7, 0, 0, 0, time.UTC)
+	db := setup(t, data.ClockFunc(func() time.Time { return now }))
+	notifier := &fakeNotifier{}
+	r := NewReminder(db, notifier)
+
+	check := func(want ...string) {
+		t.Helper()
+		got, err := r.Check()
+		if err != nil {
+			t.Fatalf("did not expect an error, got %v", err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v want %v", got, want)
+		}
+	}
+
+	check()
+
+	now = now.Add(2 * time.Hour)
+	check("stretch", "walk")
+	if notifier.sent[1].message != "Not done yet today: around the block" {
+		t.Errorf("got message %q", notifier.sent[1].message)
+	}
+
+	// a reminder goes off only once
+	now = now.Add(time.Hour)
+	check()
+
+	if _, err := db.RecordCompletion("read"); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(10 * time.Hour)
+	check()
+
+	// the next day starts over
+	now = now.Add(13 * time.Hour)
+	check("stretch", "walk")
+}
+
+func TestCheckFirstCoversToday(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 21, 0, 0, 0, time.UTC)
+	db := setup(t, data.ClockFunc(func() time.Time { return now }))
+	r := NewReminder(db, &fakeNotifier{})
+
+	got, err := r.Check()
+	if err != nil {
+		t.Fatalf("did not expect an error, got %v", err)
+	}
+	if want := []string{"stretch", "walk", "read"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+}
+
+func TestCheckNotifierFails(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 21, 0, 0, 0, time.UTC)
+	db := setup(t, data.ClockFunc(func() time.Time { return now }))
+	r := NewReminder(db, &fakeNotifier{err: errors.New("no display")})
+
+	got, err := r.Check()
+	if err == nil || !strings.Contains(err.Error(), "no display") {
+		t.Errorf("expected the notifier's error, got %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %v want none notified", got)
+	}
+}
+
+func TestCheckRetriesFailedNotifications(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 9, 30, 0, 0, time.UTC)
+	db := setup(t, data.ClockFunc(func() time.Time { return now }))
+	r := NewReminder(db, &fakeNotifier{failures: 1})
+
+	got, err := r.Check()
+	if err == nil || !strings.Contains(err.Error(), "stretch: no display") {
+		t.Errorf("expected the notifier's error for stretch, got %v", err)
+	}
+	if want := []string{"walk"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	now = now.Add(time.Minute)
+	got, err = r.Check()
+	if err != nil {
+		t.Fatalf("did not expect an error, got %v", err)
+	}
+	if want := []string{"stretch"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v want %v", got, want)
+	}
+
+	// once sent it isn't retried again
+	now = now.Add(time.Minute)
+	got, err = r.Check()
+	if err != nil {
+		t.Fatalf("did not expect an error, got %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %v want none notified", got)
+	}
+}
+
+func TestCheckSkipsFailedNotificationsDoneSince(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 9, 30, 0, 0, time.UTC)
+	db := setup(t, data.ClockFunc(func() time.Time { return now }))
+	r := NewReminder(db, &fakeNotifier{failures: 1})
+
+	if _, err := r.Check(); err == nil {
+		t.Fatal("expected the notifier's error")
+	}
+	if _, err := db.RecordCompletion("stretch"); err != nil {
+		t.Fatal(err)
+	}
+
+	now = now.Add(time.Minute)
+	got, err := r.Check()
+	if err != nil {
+		t.Fatalf("did not expect an error, got %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %v want none notified", got)
+	}
+}
+
+func TestRun(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 21, 0, 0, 0, time.UTC)
+	db := setup(t, data.ClockFunc(func() time.Time { return now }))
+	var out, errs bytes.Buffer
+	r := NewReminder(db, Bell{Out: &out})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.Run(ctx, time.Minute, &errs); err != nil {
+		t.Fatalf("did not expect an error, got %v", err)
+	}
+
+	want := "\astretch: Not done yet today\n\awalk: Not done yet today: around the block\n\aread: Not done yet today\n"
+	if out.String() != want {
+		t.Errorf("got %q want %q", out.String(), want)
+	}
+	if errs.Len() != 0 {
+		t.Errorf("got errors %q", errs.String())
+	}
+}
+
+// setup returns a database with habits reminded of at 8:00, 9:00 and 20:00
+// and one without a reminder
+func setup(t *testing.T, clock data.Clock) data.Database {
+	t.Helper()
+	gdb, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("unable to open in-memory SQLite DB: %v", err)
+	}
+	if _, err := data.Migrate(gdb); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := gdb.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	db := data.Database{DB: gdb, Calendar: data.Calendar{Location: time.UTC}, Clock: clock}
+	reminders := []struct{ habit, description, at string }{
+		{"stretch", "", "08:00"},
+		{"walk", "around the block", "09:00"},
+		{"read", "", "20:00"},
+		{"cook", "", ""},
+	}
+	for _, r := range reminders {
+		if _, err := db.CreateHabit(r.habit); err != nil {
+			t.Fatal(err)
+		}
+		_, err := db.EditHabit(r.habit, data.HabitEdit{Name: r.habit, Description: r.description, RemindAt: r.at})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}